@@ -0,0 +1,115 @@
+package database
+
+import (
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "blade-ingestion-service/server/utils"
+
+    "gorm.io/driver/postgres"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+)
+
+// Connect opens a pooled GORM connection to Postgres
+func Connect(config *utils.Config) (*gorm.DB, error) {
+    db, err := gorm.Open(postgres.Open(buildDSN(config)), &gorm.Config{
+        Logger: logger.Default.LogMode(gormLogLevel(config.LogLevel)),
+        NowFunc: func() time.Time {
+            return time.Now().UTC()
+        },
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to open database: %w", err)
+    }
+
+    sqlDB, err := db.DB()
+    if err != nil {
+        return nil, fmt.Errorf("failed to get database handle: %w", err)
+    }
+
+    if config.DBMaxOpenConns > 0 {
+        sqlDB.SetMaxOpenConns(config.DBMaxOpenConns)
+    }
+    if config.DBMaxIdleConns > 0 {
+        sqlDB.SetMaxIdleConns(config.DBMaxIdleConns)
+    }
+    if config.DBConnMaxLifetime > 0 {
+        sqlDB.SetConnMaxLifetime(config.DBConnMaxLifetime)
+    }
+
+    if err := sqlDB.Ping(); err != nil {
+        sqlDB.Close()
+        return nil, fmt.Errorf("failed to ping database: %w", err)
+    }
+
+    if config.DBSchema != "" && config.DBSchema != "public" {
+        if err := db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %q", config.DBSchema)).Error; err != nil {
+            sqlDB.Close()
+            return nil, fmt.Errorf("failed to create schema %s: %w", config.DBSchema, err)
+        }
+    }
+
+    log.Printf("Connected to database %s at %s:%s", config.DBName, config.DBHost, config.DBPort)
+    return db, nil
+}
+
+// Close closes the underlying connection pool
+func Close(db *gorm.DB) error {
+    sqlDB, err := db.DB()
+    if err != nil {
+        return err
+    }
+    return sqlDB.Close()
+}
+
+// buildDSN builds a Postgres connection string from configuration. Values
+// are quoted so spaces, quotes or backslashes in them cannot break the
+// string or add keywords to it.
+func buildDSN(config *utils.Config) string {
+    sslMode := "disable"
+    if config.UseSSL {
+        sslMode = "require"
+    }
+
+    params := [][2]string{
+        {"host", config.DBHost},
+        {"port", config.DBPort},
+        {"user", config.DBUser},
+        {"password", config.DBPassword},
+        {"dbname", config.DBName},
+        {"sslmode", sslMode},
+    }
+    if config.DBSchema != "" {
+        params = append(params, [2]string{"search_path", config.DBSchema})
+    }
+
+    parts := make([]string, len(params))
+    for i, p := range params {
+        parts[i] = p[0] + "=" + quoteDSNValue(p[1])
+    }
+    return strings.Join(parts, " ")
+}
+
+// quoteDSNValue quotes a libpq keyword/value connection string value
+func quoteDSNValue(value string) string {
+    value = strings.ReplaceAll(value, `\`, `\\`)
+    value = strings.ReplaceAll(value, `'`, `\'`)
+    return "'" + value + "'"
+}
+
+// gormLogLevel maps the service log level to a GORM log level
+func gormLogLevel(level string) logger.LogLevel {
+    switch level {
+    case "debug":
+        return logger.Info
+    case "info", "warn", "warning":
+        return logger.Warn
+    case "error":
+        return logger.Error
+    default:
+        return logger.Silent
+    }
+}
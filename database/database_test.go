@@ -0,0 +1,33 @@
+package database
+
+import (
+    "testing"
+
+    "blade-ingestion-service/server/utils"
+
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestBuildDSNQuotesValues(t *testing.T) {
+    dsn := buildDSN(&utils.Config{
+        DBHost:     "db",
+        DBPort:     "5432",
+        DBUser:     "blade",
+        DBPassword: `p'a ss\ sslmode=disable`,
+        DBName:     "blade",
+        DBSchema:   "blade data",
+        UseSSL:     true,
+    })
+
+    // Every value survives parsing intact, and none adds a keyword
+    config, err := pgconn.ParseConfig(dsn)
+    require.NoError(t, err)
+    assert.Equal(t, "db", config.Host)
+    assert.Equal(t, "blade", config.User)
+    assert.Equal(t, `p'a ss\ sslmode=disable`, config.Password)
+    assert.Equal(t, "blade", config.Database)
+    assert.Equal(t, "blade data", config.RuntimeParams["search_path"])
+    assert.NotNil(t, config.TLSConfig)
+}
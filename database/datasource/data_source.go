@@ -1,6 +1,7 @@
 package datasource
 
 import (
+    "encoding/json"
     "time"
     "gorm.io/gorm"
     "gorm.io/datatypes"
@@ -20,6 +21,12 @@ type DataSource struct {
     SyncSchedule     string         `json:"sync_schedule,omitempty"` // Cron expression
     LastSyncTime     *time.Time     `json:"last_sync_time,omitempty"`
     LastSyncStatus   string         `json:"last_sync_status,omitempty"`
+    NextSyncTime     *time.Time     `json:"next_sync_time,omitempty"`
+    LastScheduledRun *time.Time     `json:"last_scheduled_run,omitempty"`
+    
+    // Incremental sync reads rows whose WatermarkColumn is past Watermark
+    WatermarkColumn  string         `json:"watermark_column,omitempty"`
+    Watermark        *time.Time     `json:"watermark,omitempty"`
     
     // Statistics
     ItemCount        int            `json:"item_count"`
@@ -35,7 +42,10 @@ type DataSource struct {
 // GetParameters unmarshals parameters JSON
 func (ds *DataSource) GetParameters() (map[string]interface{}, error) {
     var params map[string]interface{}
-    if err := ds.Parameters.Scan(&params); err != nil {
+    if len(ds.Parameters) == 0 {
+        return params, nil
+    }
+    if err := json.Unmarshal(ds.Parameters, &params); err != nil {
         return nil, err
     }
     return params, nil
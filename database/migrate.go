@@ -0,0 +1,216 @@
+package database
+
+import (
+    "embed"
+    "fmt"
+    "io/fs"
+    "log"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the Postgres advisory lock key held while migrating
+const migrationLockID = 727274
+
+// Migration is a single versioned schema change
+type Migration struct {
+    Version int
+    Name    string
+    Up      string
+    Down    string
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+    Version   int       `gorm:"primaryKey;autoIncrement:false"`
+    Name      string    `gorm:"not null"`
+    AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for applied migrations
+func (SchemaMigration) TableName() string {
+    return "schema_migrations"
+}
+
+// LoadMigrations reads the embedded migrations ordered by version.
+// Files are named NNNN_description.up.sql and NNNN_description.down.sql.
+func LoadMigrations() ([]Migration, error) {
+    entries, err := fs.ReadDir(migrationFiles, "migrations")
+    if err != nil {
+        return nil, fmt.Errorf("failed to read migrations: %w", err)
+    }
+
+    byVersion := map[int]*Migration{}
+    for _, entry := range entries {
+        fileName := entry.Name()
+
+        var direction string
+        switch {
+        case strings.HasSuffix(fileName, ".up.sql"):
+            direction = "up"
+        case strings.HasSuffix(fileName, ".down.sql"):
+            direction = "down"
+        default:
+            return nil, fmt.Errorf("unexpected migration file %s", fileName)
+        }
+
+        base := strings.TrimSuffix(fileName, "."+direction+".sql")
+        versionPart, name, ok := strings.Cut(base, "_")
+        if !ok {
+            return nil, fmt.Errorf("migration %s is missing a description", fileName)
+        }
+        version, err := strconv.Atoi(versionPart)
+        if err != nil || version <= 0 {
+            return nil, fmt.Errorf("migration %s has an invalid version", fileName)
+        }
+
+        contents, err := migrationFiles.ReadFile(path.Join("migrations", fileName))
+        if err != nil {
+            return nil, fmt.Errorf("failed to read migration %s: %w", fileName, err)
+        }
+
+        m, exists := byVersion[version]
+        if !exists {
+            m = &Migration{Version: version, Name: name}
+            byVersion[version] = m
+        } else if m.Name != name {
+            return nil, fmt.Errorf("migration version %d has conflicting names %s and %s", version, m.Name, name)
+        }
+
+        if direction == "up" {
+            m.Up = string(contents)
+        } else {
+            m.Down = string(contents)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" || m.Down == "" {
+            return nil, fmt.Errorf("migration %04d_%s must have both up and down files", m.Version, m.Name)
+        }
+        migrations = append(migrations, *m)
+    }
+    sort.Slice(migrations, func(i, j int) bool {
+        return migrations[i].Version < migrations[j].Version
+    })
+
+    return migrations, nil
+}
+
+// Migrate applies all pending migrations in version order
+func Migrate(db *gorm.DB) error {
+    migrations, err := LoadMigrations()
+    if err != nil {
+        return err
+    }
+
+    return withMigrationLock(db, func(conn *gorm.DB) error {
+        applied, err := appliedVersions(conn)
+        if err != nil {
+            return err
+        }
+
+        for _, m := range migrations {
+            if applied[m.Version] {
+                continue
+            }
+
+            err := conn.Transaction(func(tx *gorm.DB) error {
+                if err := tx.Exec(m.Up).Error; err != nil {
+                    return err
+                }
+                return tx.Create(&SchemaMigration{
+                    Version:   m.Version,
+                    Name:      m.Name,
+                    AppliedAt: time.Now().UTC(),
+                }).Error
+            })
+            if err != nil {
+                return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+            }
+            log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+        }
+
+        return nil
+    })
+}
+
+// Rollback reverts the most recently applied migrations, newest first
+func Rollback(db *gorm.DB, steps int) error {
+    if steps <= 0 {
+        return fmt.Errorf("rollback steps must be positive")
+    }
+
+    migrations, err := LoadMigrations()
+    if err != nil {
+        return err
+    }
+
+    return withMigrationLock(db, func(conn *gorm.DB) error {
+        applied, err := appliedVersions(conn)
+        if err != nil {
+            return err
+        }
+
+        for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+            m := migrations[i]
+            if !applied[m.Version] {
+                continue
+            }
+
+            err := conn.Transaction(func(tx *gorm.DB) error {
+                if err := tx.Exec(m.Down).Error; err != nil {
+                    return err
+                }
+                return tx.Delete(&SchemaMigration{}, m.Version).Error
+            })
+            if err != nil {
+                return fmt.Errorf("rollback of %04d_%s failed: %w", m.Version, m.Name, err)
+            }
+            log.Printf("Rolled back migration %04d_%s", m.Version, m.Name)
+            steps--
+        }
+
+        return nil
+    })
+}
+
+// appliedVersions returns the set of applied migration versions
+func appliedVersions(db *gorm.DB) (map[int]bool, error) {
+    if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+        return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+    }
+
+    var rows []SchemaMigration
+    if err := db.Find(&rows).Error; err != nil {
+        return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+    }
+
+    applied := make(map[int]bool, len(rows))
+    for _, row := range rows {
+        applied[row.Version] = true
+    }
+    return applied, nil
+}
+
+// withMigrationLock runs fn on a single connection holding an advisory lock,
+// so that concurrently starting replicas do not migrate at the same time
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+    return db.Connection(func(conn *gorm.DB) error {
+        if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+            return fmt.Errorf("failed to acquire migration lock: %w", err)
+        }
+        defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+
+        return fn(conn)
+    })
+}
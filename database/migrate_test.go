@@ -0,0 +1,27 @@
+package database
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+    migrations, err := LoadMigrations()
+
+    assert.NoError(t, err)
+    assert.NotEmpty(t, migrations)
+
+    // Versions are unique, ascending and every migration is reversible
+    for i, m := range migrations {
+        assert.NotEmpty(t, m.Name)
+        assert.NotEmpty(t, m.Up)
+        assert.NotEmpty(t, m.Down)
+        if i > 0 {
+            assert.Greater(t, m.Version, migrations[i-1].Version)
+        }
+    }
+
+    assert.Equal(t, 1, migrations[0].Version)
+    assert.Equal(t, "create_core_tables", migrations[0].Name)
+}
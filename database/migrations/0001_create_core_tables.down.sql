@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS blade_items;
+DROP TABLE IF EXISTS data_sources;
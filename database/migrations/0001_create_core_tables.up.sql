@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS data_sources (
+    id                 BIGSERIAL PRIMARY KEY,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ,
+    deleted_at         TIMESTAMPTZ,
+    type_name          TEXT NOT NULL,
+    display_name       TEXT,
+    data_type          TEXT,
+    enabled            BOOLEAN,
+    parameters         JSONB,
+    sync_enabled       BOOLEAN,
+    sync_schedule      TEXT,
+    last_sync_time     TIMESTAMPTZ,
+    last_sync_status   TEXT,
+    item_count         BIGINT,
+    last_error_message TEXT,
+    warehouse_id       TEXT,
+    catalog_name       TEXT,
+    schema_name        TEXT,
+    table_name         TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_sources_type_name ON data_sources (type_name);
+CREATE INDEX IF NOT EXISTS idx_data_sources_data_type ON data_sources (data_type);
+CREATE INDEX IF NOT EXISTS idx_data_sources_deleted_at ON data_sources (deleted_at);
+
+CREATE TABLE IF NOT EXISTS blade_items (
+    id                     BIGSERIAL PRIMARY KEY,
+    created_at             TIMESTAMPTZ,
+    updated_at             TIMESTAMPTZ,
+    deleted_at             TIMESTAMPTZ,
+    item_id                TEXT NOT NULL,
+    data_type              TEXT NOT NULL,
+    data                   JSONB,
+    classification_marking TEXT,
+    last_modified          TIMESTAMPTZ,
+    metadata               JSONB,
+    data_source_id         BIGINT,
+    ingestion_job_id       TEXT,
+    catalog_id             TEXT,
+    uploaded_at            TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_blade_items_item_id ON blade_items (item_id);
+CREATE INDEX IF NOT EXISTS idx_blade_items_data_type ON blade_items (data_type);
+CREATE INDEX IF NOT EXISTS idx_blade_items_data_source_id ON blade_items (data_source_id);
+CREATE INDEX IF NOT EXISTS idx_blade_items_ingestion_job_id ON blade_items (ingestion_job_id);
+CREATE INDEX IF NOT EXISTS idx_blade_items_deleted_at ON blade_items (deleted_at);
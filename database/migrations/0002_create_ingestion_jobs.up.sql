@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS ingestion_jobs (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    updated_at        TIMESTAMPTZ,
+    deleted_at        TIMESTAMPTZ,
+    job_id            TEXT NOT NULL,
+    job_type          TEXT NOT NULL,
+    status            TEXT NOT NULL,
+    data_type         TEXT,
+    sql_query         TEXT,
+    parameters        JSONB,
+    current_operation TEXT,
+    total_items       BIGINT NOT NULL DEFAULT 0,
+    processed_items   BIGINT NOT NULL DEFAULT 0,
+    success_count     BIGINT NOT NULL DEFAULT 0,
+    error_count       BIGINT NOT NULL DEFAULT 0,
+    recent_errors     JSONB,
+    started_at        TIMESTAMPTZ,
+    completed_at      TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingestion_jobs_job_id ON ingestion_jobs (job_id);
+CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_job_type ON ingestion_jobs (job_type);
+CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_status ON ingestion_jobs (status);
+CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_deleted_at ON ingestion_jobs (deleted_at);
@@ -0,0 +1,4 @@
+ALTER TABLE ingestion_jobs DROP COLUMN IF EXISTS skipped_count;
+
+ALTER TABLE blade_items DROP COLUMN IF EXISTS uploaded_hash;
+ALTER TABLE blade_items DROP COLUMN IF EXISTS content_hash;
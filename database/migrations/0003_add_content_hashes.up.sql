@@ -0,0 +1,4 @@
+ALTER TABLE blade_items ADD COLUMN IF NOT EXISTS content_hash TEXT;
+ALTER TABLE blade_items ADD COLUMN IF NOT EXISTS uploaded_hash TEXT;
+
+ALTER TABLE ingestion_jobs ADD COLUMN IF NOT EXISTS skipped_count BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE blade_items DROP COLUMN IF EXISTS retracted_at;
+ALTER TABLE blade_items DROP COLUMN IF EXISTS last_action_at;
+ALTER TABLE blade_items DROP COLUMN IF EXISTS last_action;
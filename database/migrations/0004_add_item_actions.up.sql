@@ -0,0 +1,3 @@
+ALTER TABLE blade_items ADD COLUMN IF NOT EXISTS last_action TEXT;
+ALTER TABLE blade_items ADD COLUMN IF NOT EXISTS last_action_at TIMESTAMPTZ;
+ALTER TABLE blade_items ADD COLUMN IF NOT EXISTS retracted_at TIMESTAMPTZ;
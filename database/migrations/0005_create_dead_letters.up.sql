@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id                     BIGSERIAL PRIMARY KEY,
+    created_at             TIMESTAMPTZ,
+    updated_at             TIMESTAMPTZ,
+    deleted_at             TIMESTAMPTZ,
+    item_id                TEXT NOT NULL,
+    data_type              TEXT NOT NULL,
+    job_id                 TEXT,
+    classification_marking TEXT,
+    last_modified          TIMESTAMPTZ,
+    payload                JSONB,
+    metadata               JSONB,
+    last_error             TEXT,
+    attempts               BIGINT NOT NULL DEFAULT 0,
+    first_failed_at        TIMESTAMPTZ,
+    last_failed_at         TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dead_letters_item_id ON dead_letters (item_id);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_data_type ON dead_letters (data_type);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_job_id ON dead_letters (job_id);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_deleted_at ON dead_letters (deleted_at);
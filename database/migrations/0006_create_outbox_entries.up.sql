@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS outbox_entries (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ,
+    item_id      TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    status       TEXT NOT NULL,
+    attempts     BIGINT NOT NULL DEFAULT 0,
+    last_error   TEXT,
+    available_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_entries_item_id ON outbox_entries (item_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_entries_status ON outbox_entries (status);
+CREATE INDEX IF NOT EXISTS idx_outbox_entries_pending ON outbox_entries (available_at) WHERE status = 'pending';
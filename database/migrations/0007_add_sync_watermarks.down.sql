@@ -0,0 +1,2 @@
+ALTER TABLE data_sources DROP COLUMN IF EXISTS watermark;
+ALTER TABLE data_sources DROP COLUMN IF EXISTS watermark_column;
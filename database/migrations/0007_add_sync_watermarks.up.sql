@@ -0,0 +1,2 @@
+ALTER TABLE data_sources ADD COLUMN IF NOT EXISTS watermark_column TEXT;
+ALTER TABLE data_sources ADD COLUMN IF NOT EXISTS watermark TIMESTAMPTZ;
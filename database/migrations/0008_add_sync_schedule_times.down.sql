@@ -0,0 +1,2 @@
+ALTER TABLE data_sources DROP COLUMN IF EXISTS last_scheduled_run;
+ALTER TABLE data_sources DROP COLUMN IF EXISTS next_sync_time;
@@ -0,0 +1,2 @@
+ALTER TABLE data_sources ADD COLUMN IF NOT EXISTS next_sync_time TIMESTAMPTZ;
+ALTER TABLE data_sources ADD COLUMN IF NOT EXISTS last_scheduled_run TIMESTAMPTZ;
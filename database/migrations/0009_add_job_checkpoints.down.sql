@@ -0,0 +1,2 @@
+ALTER TABLE ingestion_jobs DROP COLUMN IF EXISTS resume_count;
+ALTER TABLE ingestion_jobs DROP COLUMN IF EXISTS checkpoint;
@@ -0,0 +1,2 @@
+ALTER TABLE ingestion_jobs ADD COLUMN IF NOT EXISTS checkpoint JSONB;
+ALTER TABLE ingestion_jobs ADD COLUMN IF NOT EXISTS resume_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE blade_items DROP COLUMN IF EXISTS delivered_sinks;
@@ -0,0 +1,5 @@
+-- Sinks that accepted each item's current content, keyed by sink name.
+-- Part of pluggable sinks, but added after job checkpoints took version
+-- 0009. Moving it ahead would renumber a migration databases have already
+-- recorded as applied.
+ALTER TABLE blade_items ADD COLUMN IF NOT EXISTS delivered_sinks JSONB;
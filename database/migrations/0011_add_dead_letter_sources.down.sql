@@ -0,0 +1 @@
+ALTER TABLE dead_letters DROP COLUMN IF EXISTS data_source_id;
@@ -0,0 +1 @@
+ALTER TABLE dead_letters ADD COLUMN IF NOT EXISTS data_source_id BIGINT;
@@ -1,6 +1,11 @@
 package models
 
 import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
     "time"
     "gorm.io/gorm"
     "gorm.io/datatypes"
@@ -16,6 +21,13 @@ const (
     LogisticsData   BLADEItemType = "logistics"
 )
 
+// Catalog actions recorded on a BLADEItem
+const (
+    ItemActionCreated   = "created"
+    ItemActionUpdated   = "updated"
+    ItemActionRetracted = "retracted"
+)
+
 // BLADEItem represents a generic BLADE data item in the database
 type BLADEItem struct {
     gorm.Model
@@ -31,6 +43,82 @@ type BLADEItem struct {
     IngestionJobID string    `gorm:"index" json:"ingestion_job_id,omitempty"`
     CatalogID      string    `json:"catalog_id,omitempty"`
     UploadedAt     *time.Time `json:"uploaded_at,omitempty"`
+    
+    // ContentHash identifies the current content; UploadedHash is the
+    // content hash at the last successful upload
+    ContentHash    string    `json:"content_hash,omitempty"`
+    UploadedHash   string    `json:"uploaded_hash,omitempty"`
+    
+    // Last catalog action taken for the item, and when it was retracted
+    LastAction     string     `json:"last_action,omitempty"`
+    LastActionAt   *time.Time `json:"last_action_at,omitempty"`
+    RetractedAt    *time.Time `json:"retracted_at,omitempty"`
+    
+    // DeliveredSinks maps the name of each sink that accepted the item to
+    // the content hash it accepted
+    DeliveredSinks datatypes.JSON `json:"delivered_sinks,omitempty"`
+}
+
+// ComputeContentHash returns a SHA-256 hash of the item's data and
+// classification. The data is canonicalized first, so key order and
+// whitespace do not change the hash.
+func (i *BLADEItem) ComputeContentHash() (string, error) {
+    var data interface{}
+    if len(i.Data) > 0 {
+        dec := json.NewDecoder(bytes.NewReader(i.Data))
+        dec.UseNumber()
+        if err := dec.Decode(&data); err != nil {
+            return "", fmt.Errorf("failed to canonicalize data: %w", err)
+        }
+    }
+    
+    // encoding/json writes map keys in sorted order
+    canonical, err := json.Marshal(data)
+    if err != nil {
+        return "", fmt.Errorf("failed to canonicalize data: %w", err)
+    }
+    
+    h := sha256.New()
+    h.Write(canonical)
+    h.Write([]byte{0})
+    h.Write([]byte(i.ClassificationMarking))
+    return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DeliveredTo reports whether a sink has accepted the item's current content
+func (i *BLADEItem) DeliveredTo(sink string) bool {
+    if i.ContentHash == "" || len(i.DeliveredSinks) == 0 {
+        return false
+    }
+    var delivered map[string]string
+    if err := json.Unmarshal(i.DeliveredSinks, &delivered); err != nil {
+        return false
+    }
+    return delivered[sink] == i.ContentHash
+}
+
+// SetDeliveredTo records that a sink accepted the item's current content.
+// Sinks that accepted older content are dropped.
+func (i *BLADEItem) SetDeliveredTo(sink string) error {
+    delivered := map[string]string{}
+    if len(i.DeliveredSinks) > 0 {
+        if err := json.Unmarshal(i.DeliveredSinks, &delivered); err != nil {
+            return fmt.Errorf("invalid delivered sinks: %w", err)
+        }
+    }
+    for name, hash := range delivered {
+        if hash != i.ContentHash {
+            delete(delivered, name)
+        }
+    }
+    delivered[sink] = i.ContentHash
+    
+    data, err := json.Marshal(delivered)
+    if err != nil {
+        return err
+    }
+    i.DeliveredSinks = data
+    return nil
 }
 
 // TableName specifies the table name for BLADE items
@@ -121,6 +209,22 @@ func GetBLADEItemType(itemType string) BLADEItemType {
     }
 }
 
+// NewBLADERecord returns an empty typed record for the item type
+func NewBLADERecord(itemType BLADEItemType) interface{} {
+    switch itemType {
+    case MaintenanceData:
+        return &BLADEMaintenanceData{}
+    case SortieData:
+        return &BLADESortieData{}
+    case DeploymentData:
+        return &BLADEDeploymentData{}
+    case LogisticsData:
+        return &BLADELogisticsData{}
+    default:
+        return nil
+    }
+}
+
 // GetDefaultClassification returns default classification for item type
 func GetDefaultClassification(itemType BLADEItemType) string {
     switch itemType {
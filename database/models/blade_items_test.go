@@ -0,0 +1,42 @@
+package models
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestComputeContentHash(t *testing.T) {
+    a := &BLADEItem{Data: []byte(`{"priority": "HIGH", "hours": 1.50, "tags": ["a"]}`), ClassificationMarking: "SECRET"}
+    b := &BLADEItem{Data: []byte(`{"tags":["a"],"hours":1.50,"priority":"HIGH"}`), ClassificationMarking: "SECRET"}
+
+    hashA, err := a.ComputeContentHash()
+    assert.NoError(t, err)
+    hashB, err := b.ComputeContentHash()
+    assert.NoError(t, err)
+    assert.Equal(t, hashA, hashB)
+
+    // Classification and data changes produce a different hash
+    b.ClassificationMarking = "UNCLASSIFIED"
+    hashC, _ := b.ComputeContentHash()
+    assert.NotEqual(t, hashA, hashC)
+
+    a.Data = []byte(`{"priority": "LOW", "hours": 1.50, "tags": ["a"]}`)
+    hashD, _ := a.ComputeContentHash()
+    assert.NotEqual(t, hashA, hashD)
+}
+
+func TestDeliveredSinks(t *testing.T) {
+    item := &BLADEItem{ContentHash: "h1"}
+    assert.False(t, item.DeliveredTo("catalog"))
+
+    assert.NoError(t, item.SetDeliveredTo("catalog"))
+    assert.True(t, item.DeliveredTo("catalog"))
+    assert.False(t, item.DeliveredTo("ndjson out"))
+
+    // New content has to be delivered again, and older deliveries are dropped
+    item.ContentHash = "h2"
+    assert.False(t, item.DeliveredTo("catalog"))
+    assert.NoError(t, item.SetDeliveredTo("ndjson out"))
+    assert.JSONEq(t, `{"ndjson out": "h2"}`, string(item.DeliveredSinks))
+}
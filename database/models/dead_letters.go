@@ -0,0 +1,48 @@
+package models
+
+import (
+    "time"
+    "gorm.io/gorm"
+    "gorm.io/datatypes"
+)
+
+// DeadLetter holds an item that could not be delivered to its sinks, with
+// enough of the item to replay it without querying Databricks again. Each
+// item has at most one dead letter; it is soft deleted once the item is
+// delivered or an operator discards it.
+type DeadLetter struct {
+    gorm.Model
+    ItemID                string         `gorm:"uniqueIndex;not null" json:"item_id"`
+    DataType              string         `gorm:"index;not null" json:"data_type"`
+    JobID                 string         `gorm:"index" json:"job_id,omitempty"`
+    DataSourceID          uint           `json:"data_source_id,omitempty"`
+    ClassificationMarking string         `json:"classification_marking"`
+    LastModified          time.Time      `json:"last_modified"`
+    Payload               datatypes.JSON `json:"payload"`
+    Metadata              datatypes.JSON `json:"metadata,omitempty"`
+    
+    // Failed delivery attempts since the item was dead-lettered
+    LastError             string         `json:"last_error"`
+    Attempts              int            `json:"attempts"`
+    FirstFailedAt         time.Time      `json:"first_failed_at"`
+    LastFailedAt          time.Time      `json:"last_failed_at"`
+}
+
+// TableName specifies the table name for dead letters
+func (DeadLetter) TableName() string {
+    return "dead_letters"
+}
+
+// ToItem rebuilds the item so it can be ingested again
+func (d *DeadLetter) ToItem() *BLADEItem {
+    return &BLADEItem{
+        ItemID:                d.ItemID,
+        DataType:              d.DataType,
+        Data:                  d.Payload,
+        ClassificationMarking: d.ClassificationMarking,
+        LastModified:          d.LastModified,
+        Metadata:              d.Metadata,
+        IngestionJobID:        d.JobID,
+        DataSourceID:          d.DataSourceID,
+    }
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+    "time"
+    "gorm.io/gorm"
+    "gorm.io/datatypes"
+)
+
+// Ingestion job types
+const (
+    JobTypeQuery = "query"
+    JobTypeSync  = "sync"
+)
+
+// IngestionJob is the persisted state of an asynchronous ingestion job
+type IngestionJob struct {
+    gorm.Model
+    JobID            string         `gorm:"uniqueIndex;not null" json:"job_id"`
+    JobType          string         `gorm:"index;not null" json:"job_type"`
+    Status           string         `gorm:"index;not null" json:"status"`
+    DataType         string         `json:"data_type"`
+    SQLQuery         string         `json:"sql_query,omitempty"`
+    Parameters       datatypes.JSON `json:"parameters,omitempty"`
+    
+    // Progress
+    CurrentOperation string         `json:"current_operation"`
+    TotalItems       int            `json:"total_items"`
+    ProcessedItems   int            `json:"processed_items"`
+    SuccessCount     int            `json:"success_count"`
+    ErrorCount       int            `json:"error_count"`
+    SkippedCount     int            `json:"skipped_count"`
+    RecentErrors     datatypes.JSON `json:"recent_errors,omitempty"`
+    
+    // Position reached by the job, and how often it was resumed from it
+    // after an interruption
+    Checkpoint       datatypes.JSON `json:"checkpoint,omitempty"`
+    ResumeCount      int            `json:"resume_count"`
+    
+    StartedAt        *time.Time     `json:"started_at,omitempty"`
+    CompletedAt      *time.Time     `json:"completed_at,omitempty"`
+}
+
+// TableName specifies the table name for ingestion jobs
+func (IngestionJob) TableName() string {
+    return "ingestion_jobs"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+    "time"
+)
+
+// Outbox entry statuses
+const (
+    OutboxPending = "pending"
+    OutboxDone    = "done"
+    OutboxFailed  = "failed"
+)
+
+// OutboxEntry records that a stored version of an item still has to reach
+// its sinks. It is written in the same transaction as the item and marked
+// done in the same transaction that records the upload, so the local store
+// and the sinks cannot silently diverge.
+type OutboxEntry struct {
+    ID          uint       `gorm:"primaryKey" json:"id"`
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+    ItemID      string     `gorm:"index;not null" json:"item_id"`
+    ContentHash string     `gorm:"not null" json:"content_hash"`
+    Status      string     `gorm:"index;not null" json:"status"`
+    Attempts    int        `json:"attempts"`
+    LastError   string     `json:"last_error,omitempty"`
+    
+    // Entries are not retried before AvailableAt, nor while a delivery
+    // holds them until LockedUntil
+    AvailableAt time.Time  `json:"available_at"`
+    LockedUntil *time.Time `json:"locked_until,omitempty"`
+    DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+// TableName specifies the table name for outbox entries
+func (OutboxEntry) TableName() string {
+    return "outbox_entries"
+}
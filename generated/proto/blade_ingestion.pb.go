@@ -72,7 +72,7 @@ func (x SyncJobRequest_SyncType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use SyncJobRequest_SyncType.Descriptor instead.
 func (SyncJobRequest_SyncType) EnumDescriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{9, 0}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{13, 0}
 }
 
 type DataSource struct {
@@ -246,6 +246,7 @@ type BLADEQuery struct {
 	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
 	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
 	OrderBy       string                 `protobuf:"bytes,5,opt,name=orderBy,proto3" json:"orderBy,omitempty"`
+	PageToken     string                 `protobuf:"bytes,6,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -315,6 +316,13 @@ func (x *BLADEQuery) GetOrderBy() string {
 	return ""
 }
 
+func (x *BLADEQuery) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
 type BLADEQueryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*BLADEItem           `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
@@ -679,6 +687,302 @@ func (x *IngestionResponse) GetDetails() map[string]string {
 	return nil
 }
 
+type DeadLetterListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DataType      string                 `protobuf:"bytes,1,opt,name=dataType,proto3" json:"dataType,omitempty"`
+	JobId         string                 `protobuf:"bytes,2,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	ItemIds       []string               `protobuf:"bytes,3,rep,name=itemIds,proto3" json:"itemIds,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterListRequest) Reset() {
+	*x = DeadLetterListRequest{}
+	mi := &file_blade_ingestion_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterListRequest) ProtoMessage() {}
+
+func (x *DeadLetterListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blade_ingestion_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterListRequest.ProtoReflect.Descriptor instead.
+func (*DeadLetterListRequest) Descriptor() ([]byte, []int) {
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeadLetterListRequest) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *DeadLetterListRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *DeadLetterListRequest) GetItemIds() []string {
+	if x != nil {
+		return x.ItemIds
+	}
+	return nil
+}
+
+func (x *DeadLetterListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *DeadLetterListRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type DeadLetterList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeadLetters   []*DeadLetter          `protobuf:"bytes,1,rep,name=deadLetters,proto3" json:"deadLetters,omitempty"`
+	TotalCount    int64                  `protobuf:"varint,2,opt,name=totalCount,proto3" json:"totalCount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterList) Reset() {
+	*x = DeadLetterList{}
+	mi := &file_blade_ingestion_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterList) ProtoMessage() {}
+
+func (x *DeadLetterList) ProtoReflect() protoreflect.Message {
+	mi := &file_blade_ingestion_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterList.ProtoReflect.Descriptor instead.
+func (*DeadLetterList) Descriptor() ([]byte, []int) {
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeadLetterList) GetDeadLetters() []*DeadLetter {
+	if x != nil {
+		return x.DeadLetters
+	}
+	return nil
+}
+
+func (x *DeadLetterList) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+type DeadLetterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterRequest) Reset() {
+	*x = DeadLetterRequest{}
+	mi := &file_blade_ingestion_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterRequest) ProtoMessage() {}
+
+func (x *DeadLetterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blade_ingestion_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterRequest.ProtoReflect.Descriptor instead.
+func (*DeadLetterRequest) Descriptor() ([]byte, []int) {
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeadLetterRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeadLetter struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Id                    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ItemId                string                 `protobuf:"bytes,2,opt,name=itemId,proto3" json:"itemId,omitempty"`
+	DataType              string                 `protobuf:"bytes,3,opt,name=dataType,proto3" json:"dataType,omitempty"`
+	JobId                 string                 `protobuf:"bytes,4,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	ClassificationMarking string                 `protobuf:"bytes,5,opt,name=classificationMarking,proto3" json:"classificationMarking,omitempty"`
+	LastError             string                 `protobuf:"bytes,6,opt,name=lastError,proto3" json:"lastError,omitempty"`
+	Attempts              int32                  `protobuf:"varint,7,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	FirstFailedAt         *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=firstFailedAt,proto3" json:"firstFailedAt,omitempty"`
+	LastFailedAt          *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=lastFailedAt,proto3" json:"lastFailedAt,omitempty"`
+	Payload               *structpb.Struct       `protobuf:"bytes,10,opt,name=payload,proto3" json:"payload,omitempty"`
+	Metadata              *structpb.Struct       `protobuf:"bytes,11,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *DeadLetter) Reset() {
+	*x = DeadLetter{}
+	mi := &file_blade_ingestion_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetter) ProtoMessage() {}
+
+func (x *DeadLetter) ProtoReflect() protoreflect.Message {
+	mi := &file_blade_ingestion_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetter.ProtoReflect.Descriptor instead.
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeadLetter) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetClassificationMarking() string {
+	if x != nil {
+		return x.ClassificationMarking
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetFirstFailedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstFailedAt
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetLastFailedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastFailedAt
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetPayload() *structpb.Struct {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 type SyncJobRequest struct {
 	state         protoimpl.MessageState  `protogen:"open.v1"`
 	SyncType      SyncJobRequest_SyncType `protobuf:"varint,1,opt,name=syncType,proto3,enum=blade.SyncJobRequest_SyncType" json:"syncType,omitempty"`
@@ -686,13 +990,14 @@ type SyncJobRequest struct {
 	Filter        string                  `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
 	MaxItems      int64                   `protobuf:"varint,4,opt,name=maxItems,proto3" json:"maxItems,omitempty"`
 	Options       *structpb.Struct        `protobuf:"bytes,5,opt,name=options,proto3" json:"options,omitempty"`
+	DataSourceId  uint64                  `protobuf:"varint,6,opt,name=dataSourceId,proto3" json:"dataSourceId,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SyncJobRequest) Reset() {
 	*x = SyncJobRequest{}
-	mi := &file_blade_ingestion_proto_msgTypes[9]
+	mi := &file_blade_ingestion_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -704,7 +1009,7 @@ func (x *SyncJobRequest) String() string {
 func (*SyncJobRequest) ProtoMessage() {}
 
 func (x *SyncJobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_blade_ingestion_proto_msgTypes[9]
+	mi := &file_blade_ingestion_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -717,7 +1022,7 @@ func (x *SyncJobRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SyncJobRequest.ProtoReflect.Descriptor instead.
 func (*SyncJobRequest) Descriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{9}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *SyncJobRequest) GetSyncType() SyncJobRequest_SyncType {
@@ -755,6 +1060,13 @@ func (x *SyncJobRequest) GetOptions() *structpb.Struct {
 	return nil
 }
 
+func (x *SyncJobRequest) GetDataSourceId() uint64 {
+	if x != nil {
+		return x.DataSourceId
+	}
+	return 0
+}
+
 type BLADEQueryJobRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SqlQuery      string                 `protobuf:"bytes,1,opt,name=sqlQuery,proto3" json:"sqlQuery,omitempty"`
@@ -767,7 +1079,7 @@ type BLADEQueryJobRequest struct {
 
 func (x *BLADEQueryJobRequest) Reset() {
 	*x = BLADEQueryJobRequest{}
-	mi := &file_blade_ingestion_proto_msgTypes[10]
+	mi := &file_blade_ingestion_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -779,7 +1091,7 @@ func (x *BLADEQueryJobRequest) String() string {
 func (*BLADEQueryJobRequest) ProtoMessage() {}
 
 func (x *BLADEQueryJobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_blade_ingestion_proto_msgTypes[10]
+	mi := &file_blade_ingestion_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -792,7 +1104,7 @@ func (x *BLADEQueryJobRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BLADEQueryJobRequest.ProtoReflect.Descriptor instead.
 func (*BLADEQueryJobRequest) Descriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{10}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *BLADEQueryJobRequest) GetSqlQuery() string {
@@ -832,7 +1144,7 @@ type JobRequest struct {
 
 func (x *JobRequest) Reset() {
 	*x = JobRequest{}
-	mi := &file_blade_ingestion_proto_msgTypes[11]
+	mi := &file_blade_ingestion_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -844,7 +1156,7 @@ func (x *JobRequest) String() string {
 func (*JobRequest) ProtoMessage() {}
 
 func (x *JobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_blade_ingestion_proto_msgTypes[11]
+	mi := &file_blade_ingestion_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -857,7 +1169,7 @@ func (x *JobRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
 func (*JobRequest) Descriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{11}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *JobRequest) GetJobId() string {
@@ -879,7 +1191,7 @@ type JobResponse struct {
 
 func (x *JobResponse) Reset() {
 	*x = JobResponse{}
-	mi := &file_blade_ingestion_proto_msgTypes[12]
+	mi := &file_blade_ingestion_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -891,7 +1203,7 @@ func (x *JobResponse) String() string {
 func (*JobResponse) ProtoMessage() {}
 
 func (x *JobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_blade_ingestion_proto_msgTypes[12]
+	mi := &file_blade_ingestion_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -904,7 +1216,7 @@ func (x *JobResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use JobResponse.ProtoReflect.Descriptor instead.
 func (*JobResponse) Descriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{12}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *JobResponse) GetJobId() string {
@@ -954,7 +1266,7 @@ type JobStatusResponse struct {
 
 func (x *JobStatusResponse) Reset() {
 	*x = JobStatusResponse{}
-	mi := &file_blade_ingestion_proto_msgTypes[13]
+	mi := &file_blade_ingestion_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -966,7 +1278,7 @@ func (x *JobStatusResponse) String() string {
 func (*JobStatusResponse) ProtoMessage() {}
 
 func (x *JobStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_blade_ingestion_proto_msgTypes[13]
+	mi := &file_blade_ingestion_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -979,7 +1291,7 @@ func (x *JobStatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use JobStatusResponse.ProtoReflect.Descriptor instead.
 func (*JobStatusResponse) Descriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{13}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *JobStatusResponse) GetJobId() string {
@@ -1072,13 +1384,18 @@ type SyncStatusResponse struct {
 	EstimatedCompletion *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=estimatedCompletion,proto3" json:"estimatedCompletion,omitempty"`
 	RecentErrors        []string               `protobuf:"bytes,10,rep,name=recentErrors,proto3" json:"recentErrors,omitempty"`
 	ProgressByType      map[string]int32       `protobuf:"bytes,11,rep,name=progressByType,proto3" json:"progressByType,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+	// What the sync changed in the locally known items
+	ItemsAdded     int32 `protobuf:"varint,12,opt,name=itemsAdded,proto3" json:"itemsAdded,omitempty"`
+	ItemsUpdated   int32 `protobuf:"varint,13,opt,name=itemsUpdated,proto3" json:"itemsUpdated,omitempty"`
+	ItemsUnchanged int32 `protobuf:"varint,14,opt,name=itemsUnchanged,proto3" json:"itemsUnchanged,omitempty"`
+	ItemsDeleted   int32 `protobuf:"varint,15,opt,name=itemsDeleted,proto3" json:"itemsDeleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *SyncStatusResponse) Reset() {
 	*x = SyncStatusResponse{}
-	mi := &file_blade_ingestion_proto_msgTypes[14]
+	mi := &file_blade_ingestion_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1090,7 +1407,7 @@ func (x *SyncStatusResponse) String() string {
 func (*SyncStatusResponse) ProtoMessage() {}
 
 func (x *SyncStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_blade_ingestion_proto_msgTypes[14]
+	mi := &file_blade_ingestion_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1103,7 +1420,7 @@ func (x *SyncStatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SyncStatusResponse.ProtoReflect.Descriptor instead.
 func (*SyncStatusResponse) Descriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{14}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *SyncStatusResponse) GetJobId() string {
@@ -1183,6 +1500,34 @@ func (x *SyncStatusResponse) GetProgressByType() map[string]int32 {
 	return nil
 }
 
+func (x *SyncStatusResponse) GetItemsAdded() int32 {
+	if x != nil {
+		return x.ItemsAdded
+	}
+	return 0
+}
+
+func (x *SyncStatusResponse) GetItemsUpdated() int32 {
+	if x != nil {
+		return x.ItemsUpdated
+	}
+	return 0
+}
+
+func (x *SyncStatusResponse) GetItemsUnchanged() int32 {
+	if x != nil {
+		return x.ItemsUnchanged
+	}
+	return 0
+}
+
+func (x *SyncStatusResponse) GetItemsDeleted() int32 {
+	if x != nil {
+		return x.ItemsDeleted
+	}
+	return 0
+}
+
 type HealthResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
@@ -1195,7 +1540,7 @@ type HealthResponse struct {
 
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
-	mi := &file_blade_ingestion_proto_msgTypes[15]
+	mi := &file_blade_ingestion_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1207,7 +1552,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_blade_ingestion_proto_msgTypes[15]
+	mi := &file_blade_ingestion_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1220,7 +1565,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_blade_ingestion_proto_rawDescGZIP(), []int{15}
+	return file_blade_ingestion_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *HealthResponse) GetStatus() string {
@@ -1266,14 +1611,15 @@ const file_blade_ingestion_proto_rawDesc = "" +
 	"\x11DataSourceRequest\x12\x17\n" +
 	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\"E\n" +
 	"\x0eDataSourceList\x123\n" +
-	"\vdataSources\x18\x01 \x03(\v2\x11.blade.DataSourceR\vdataSources\"\xfc\x02\n" +
+	"\vdataSources\x18\x01 \x03(\v2\x11.blade.DataSourceR\vdataSources\"\xf4\x03\n" +
 	"\n" +
 	"BLADEQuery\x12?\n" +
 	"\bdataType\x18\x01 \x01(\tB#\x92A\x1d2\x1bType of BLADE data to query\xe0A\x02R\bdataType\x12Y\n" +
 	"\x06filter\x18\x02 \x01(\tBA\x92A>2'Optional SQL WHERE clause for filteringJ\x13\"priority = 'HIGH'\"R\x06filter\x12C\n" +
 	"\x05limit\x18\x03 \x01(\x05B-\x92A*2#Maximum number of results to returnJ\x03100R\x05limit\x12H\n" +
 	"\x06offset\x18\x04 \x01(\x05B0\x92A-2(Number of results to skip for paginationJ\x010R\x06offset\x12C\n" +
-	"\aorderBy\x18\x05 \x01(\tB)\x92A&2$Sort order (e.g., 'created_at DESC')R\aorderBy\"\x82\x01\n" +
+	"\aorderBy\x18\x05 \x01(\tB)\x92A&2$Sort order (e.g., 'created_at DESC')R\aorderBy\x12v\n" +
+	"\tpageToken\x18\x06 \x01(\tBX\x92AU2SnextPageToken of the previous page, sent with the same dataType, filter and orderByR\tpageToken\"\x82\x01\n" +
 	"\x12BLADEQueryResponse\x12&\n" +
 	"\x05items\x18\x01 \x03(\v2\x10.blade.BLADEItemR\x05items\x12\x1e\n" +
 	"\n" +
@@ -1312,19 +1658,47 @@ const file_blade_ingestion_proto_rawDesc = "" +
 	"\adetails\x18\x06 \x03(\v2%.blade.IngestionResponse.DetailsEntryR\adetails\x1a:\n" +
 	"\fDetailsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x85\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xaf\x02\n" +
+	"\x15DeadLetterListRequest\x12\x1a\n" +
+	"\bdataType\x18\x01 \x01(\tR\bdataType\x12\x14\n" +
+	"\x05jobId\x18\x02 \x01(\tR\x05jobId\x12\x18\n" +
+	"\aitemIds\x18\x03 \x03(\tR\aitemIds\x12m\n" +
+	"\x05limit\x18\x04 \x01(\x05BW\x92AT2RMaximum number of dead letters; defaults to and is capped at MAX_RECORDS_PER_QUERYR\x05limit\x12[\n" +
+	"\x06offset\x18\x05 \x01(\x05BC\x92A@2>Number of dead letters to skip when listing; ignored by replayR\x06offset\"e\n" +
+	"\x0eDeadLetterList\x123\n" +
+	"\vdeadLetters\x18\x01 \x03(\v2\x11.blade.DeadLetterR\vdeadLetters\x12\x1e\n" +
+	"\n" +
+	"totalCount\x18\x02 \x01(\x03R\n" +
+	"totalCount\"(\n" +
+	"\x11DeadLetterRequest\x12\x13\n" +
+	"\x02id\x18\x01 \x01(\x04B\x03\xe0A\x02R\x02id\"\xc0\x03\n" +
+	"\n" +
+	"DeadLetter\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x16\n" +
+	"\x06itemId\x18\x02 \x01(\tR\x06itemId\x12\x1a\n" +
+	"\bdataType\x18\x03 \x01(\tR\bdataType\x12\x14\n" +
+	"\x05jobId\x18\x04 \x01(\tR\x05jobId\x124\n" +
+	"\x15classificationMarking\x18\x05 \x01(\tR\x15classificationMarking\x12\x1c\n" +
+	"\tlastError\x18\x06 \x01(\tR\tlastError\x12\x1a\n" +
+	"\battempts\x18\a \x01(\x05R\battempts\x12@\n" +
+	"\rfirstFailedAt\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\rfirstFailedAt\x12>\n" +
+	"\flastFailedAt\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\flastFailedAt\x121\n" +
+	"\apayload\x18\n" +
+	" \x01(\v2\x17.google.protobuf.StructR\apayload\x123\n" +
+	"\bmetadata\x18\v \x01(\v2\x17.google.protobuf.StructR\bmetadata\"\xaf\x03\n" +
 	"\x0eSyncJobRequest\x12:\n" +
 	"\bsyncType\x18\x01 \x01(\x0e2\x1e.blade.SyncJobRequest.SyncTypeR\bsyncType\x12\x1a\n" +
 	"\bdataType\x18\x02 \x01(\tR\bdataType\x12\x16\n" +
 	"\x06filter\x18\x03 \x01(\tR\x06filter\x12\x1a\n" +
 	"\bmaxItems\x18\x04 \x01(\x03R\bmaxItems\x121\n" +
-	"\aoptions\x18\x05 \x01(\v2\x17.google.protobuf.StructR\aoptions\"4\n" +
+	"\aoptions\x18\x05 \x01(\v2\x17.google.protobuf.StructR\aoptions\x12\xa7\x01\n" +
+	"\fdataSourceId\x18\x06 \x01(\x04B\x82\x01\x92A\x7f2}Data source to sync; its data type is synced from it. Without one, each data type is synced from its one enabled data source.R\fdataSourceId\"4\n" +
 	"\bSyncType\x12\b\n" +
 	"\x04FULL\x10\x00\x12\x0f\n" +
 	"\vINCREMENTAL\x10\x01\x12\r\n" +
-	"\tDATA_TYPE\x10\x02\"\xa5\x04\n" +
-	"\x14BLADEQueryJobRequest\x12\x8b\x01\n" +
-	"\bsqlQuery\x18\x01 \x01(\tBo\x92Ai2'SQL query to execute against DatabricksJ>\"SELECT * FROM blade_maintenance_data WHERE priority = 'HIGH'\"\xe0A\x02R\bsqlQuery\x12D\n" +
+	"\tDATA_TYPE\x10\x02\"\xc3\x05\n" +
+	"\x14BLADEQueryJobRequest\x12\xa9\x02\n" +
+	"\bsqlQuery\x18\x01 \x01(\tB\x8c\x02\x92A\x85\x022\xc2\x01SQL query to execute against Databricks. It must return an item_id column, or the job is rejected: its rows are read in item_id order, whatever ORDER BY it has, so an interrupted job can resume.J>\"SELECT * FROM blade_maintenance_data WHERE priority = 'HIGH'\"\xe0A\x02R\bsqlQuery\x12D\n" +
 	"\bdataType\x18\x02 \x01(\tB(\x92A\"2 Type of BLADE data being queried\xe0A\x02R\bdataType\x12y\n" +
 	"\n" +
 	"parameters\x18\x03 \x03(\v2+.blade.BLADEQueryJobRequest.ParametersEntryB,\x92A)2'Additional parameters for the query jobR\n" +
@@ -1357,7 +1731,7 @@ const file_blade_ingestion_proto_rawDesc = "" +
 	"\tstartTime\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x12L\n" +
 	"\x13estimatedCompletion\x18\n" +
 	" \x01(\v2\x1a.google.protobuf.TimestampR\x13estimatedCompletion\x12\"\n" +
-	"\frecentErrors\x18\v \x03(\tR\frecentErrors\"\xc0\x04\n" +
+	"\frecentErrors\x18\v \x03(\tR\frecentErrors\"\xd0\x05\n" +
 	"\x12SyncStatusResponse\x12\x14\n" +
 	"\x05jobId\x18\x01 \x01(\tR\x05jobId\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x12*\n" +
@@ -1374,7 +1748,13 @@ const file_blade_ingestion_proto_rawDesc = "" +
 	"\x13estimatedCompletion\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x13estimatedCompletion\x12\"\n" +
 	"\frecentErrors\x18\n" +
 	" \x03(\tR\frecentErrors\x12U\n" +
-	"\x0eprogressByType\x18\v \x03(\v2-.blade.SyncStatusResponse.ProgressByTypeEntryR\x0eprogressByType\x1aA\n" +
+	"\x0eprogressByType\x18\v \x03(\v2-.blade.SyncStatusResponse.ProgressByTypeEntryR\x0eprogressByType\x12\x1e\n" +
+	"\n" +
+	"itemsAdded\x18\f \x01(\x05R\n" +
+	"itemsAdded\x12\"\n" +
+	"\fitemsUpdated\x18\r \x01(\x05R\fitemsUpdated\x12&\n" +
+	"\x0eitemsUnchanged\x18\x0e \x01(\x05R\x0eitemsUnchanged\x12\"\n" +
+	"\fitemsDeleted\x18\x0f \x01(\x05R\fitemsDeleted\x1aA\n" +
 	"\x13ProgressByTypeEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xd8\x01\n" +
@@ -1385,7 +1765,7 @@ const file_blade_ingestion_proto_rawDesc = "" +
 	"\x06uptime\x18\x04 \x01(\tR\x06uptime\x1a;\n" +
 	"\rServicesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\x84\x17\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xb5 \n" +
 	"\x15BLADEIngestionService\x12\x92\x02\n" +
 	"\x0eAddBLADESource\x12\x11.blade.DataSource\x1a\x16.google.protobuf.Empty\"\xd4\x01\x92A\xae\x01\n" +
 	"\rConfiguration\x12\x1dConfigure a BLADE data source\x1a~Adds a new Databricks data source for BLADE data. The source configuration includes connection details and data type mappings.\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/configure/blade/{name}\x12\x81\x02\n" +
@@ -1401,7 +1781,17 @@ const file_blade_ingestion_proto_rawDesc = "" +
 	"\x0fIngestBLADEItem\x12\x17.blade.BLADEItemRequest\x1a\x18.blade.IngestionResponse\"\xc0\x01\x92A\x89\x01\n" +
 	"\tIngestion\x12%Ingest specific BLADE item to catalog\x1aUFetches a specific BLADE item from Databricks and ingests it into the catalog system.\x82\xd3\xe4\x93\x02-:\bmetadata\"!/blade/{dataType}/{itemId}/ingest\x12\xdc\x01\n" +
 	"\x0fBulkIngestBLADE\x12\x1b.blade.BulkIngestionRequest\x1a\x18.blade.IngestionResponse\"\x91\x01\x92Aq\n" +
-	"\tIngestion\x12\x17Bulk ingest BLADE items\x1aKIngests multiple BLADE items based on filter criteria or specific item IDs.\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/blade/bulk-ingest\x12\xcc\x01\n" +
+	"\tIngestion\x12\x17Bulk ingest BLADE items\x1aKIngests multiple BLADE items based on filter criteria or specific item IDs.\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/blade/bulk-ingest\x12\xd9\x01\n" +
+	"\x0fListDeadLetters\x12\x1c.blade.DeadLetterListRequest\x1a\x15.blade.DeadLetterList\"\x90\x01\x92Ar\n" +
+	"\fDead Letters\x12\x11List dead letters\x1aOLists items that failed delivery, oldest failure first, without their payloads.\x82\xd3\xe4\x93\x02\x15\x12\x13/blade/dead-letters\x12\xd7\x01\n" +
+	"\rGetDeadLetter\x12\x18.blade.DeadLetterRequest\x1a\x11.blade.DeadLetter\"\x98\x01\x92Au\n" +
+	"\fDead Letters\x12\x11Get a dead letter\x1aRReturns a dead letter including the payload and metadata it will be replayed with.\x82\xd3\xe4\x93\x02\x1a\x12\x18/blade/dead-letters/{id}\x12\xf6\x01\n" +
+	"\x10ReplayDeadLetter\x12\x18.blade.DeadLetterRequest\x1a\x18.blade.IngestionResponse\"\xad\x01\x92A\x82\x01\n" +
+	"\fDead Letters\x12\x14Replay a dead letter\x1a\\Ingests the stored payload of a dead letter again. The item leaves the store once delivered.\x82\xd3\xe4\x93\x02!\"\x1f/blade/dead-letters/{id}/replay\x12\xbb\x02\n" +
+	"\x11ReplayDeadLetters\x12\x1c.blade.DeadLetterListRequest\x1a\x18.blade.IngestionResponse\"\xed\x01\x92A\xc4\x01\n" +
+	"\fDead Letters\x12\x1cReplay matching dead letters\x1a\x95\x01Ingests the stored payloads of up to limit dead letters matching the filter again. Items that fail stay in the store with their attempt count raised.\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/blade/dead-letters/replay\x12\xc1\x01\n" +
+	"\x11DiscardDeadLetter\x12\x18.blade.DeadLetterRequest\x1a\x16.google.protobuf.Empty\"z\x92AW\n" +
+	"\fDead Letters\x12\x15Discard a dead letter\x1a0Drops a dead letter without delivering its item.\x82\xd3\xe4\x93\x02\x1a*\x18/blade/dead-letters/{id}\x12\xcc\x01\n" +
 	"\x0eStartBLADESync\x12\x15.blade.SyncJobRequest\x1a\x12.blade.JobResponse\"\x8e\x01\x92Ap\n" +
 	"\x04Jobs\x12\x19Start BLADE data sync job\x1aMStarts an asynchronous job to sync BLADE data from Databricks to the catalog.\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/jobs/sync/start\x12\xa6\x01\n" +
 	"\rStopBLADESync\x12\x16.google.protobuf.Empty\x1a\x12.blade.JobResponse\"i\x92AO\n" +
@@ -1433,7 +1823,7 @@ func file_blade_ingestion_proto_rawDescGZIP() []byte {
 }
 
 var file_blade_ingestion_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_blade_ingestion_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_blade_ingestion_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
 var file_blade_ingestion_proto_goTypes = []any{
 	(SyncJobRequest_SyncType)(0),  // 0: blade.SyncJobRequest.SyncType
 	(*DataSource)(nil),            // 1: blade.DataSource
@@ -1445,75 +1835,94 @@ var file_blade_ingestion_proto_goTypes = []any{
 	(*BLADEItemRequest)(nil),      // 7: blade.BLADEItemRequest
 	(*BulkIngestionRequest)(nil),  // 8: blade.BulkIngestionRequest
 	(*IngestionResponse)(nil),     // 9: blade.IngestionResponse
-	(*SyncJobRequest)(nil),        // 10: blade.SyncJobRequest
-	(*BLADEQueryJobRequest)(nil),  // 11: blade.BLADEQueryJobRequest
-	(*JobRequest)(nil),            // 12: blade.JobRequest
-	(*JobResponse)(nil),           // 13: blade.JobResponse
-	(*JobStatusResponse)(nil),     // 14: blade.JobStatusResponse
-	(*SyncStatusResponse)(nil),    // 15: blade.SyncStatusResponse
-	(*HealthResponse)(nil),        // 16: blade.HealthResponse
-	nil,                           // 17: blade.BLADEItem.MetadataEntry
-	nil,                           // 18: blade.BulkIngestionRequest.MetadataEntry
-	nil,                           // 19: blade.IngestionResponse.DetailsEntry
-	nil,                           // 20: blade.BLADEQueryJobRequest.ParametersEntry
-	nil,                           // 21: blade.SyncStatusResponse.ProgressByTypeEntry
-	nil,                           // 22: blade.HealthResponse.ServicesEntry
-	(*structpb.Struct)(nil),       // 23: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil), // 24: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),         // 25: google.protobuf.Empty
+	(*DeadLetterListRequest)(nil), // 10: blade.DeadLetterListRequest
+	(*DeadLetterList)(nil),        // 11: blade.DeadLetterList
+	(*DeadLetterRequest)(nil),     // 12: blade.DeadLetterRequest
+	(*DeadLetter)(nil),            // 13: blade.DeadLetter
+	(*SyncJobRequest)(nil),        // 14: blade.SyncJobRequest
+	(*BLADEQueryJobRequest)(nil),  // 15: blade.BLADEQueryJobRequest
+	(*JobRequest)(nil),            // 16: blade.JobRequest
+	(*JobResponse)(nil),           // 17: blade.JobResponse
+	(*JobStatusResponse)(nil),     // 18: blade.JobStatusResponse
+	(*SyncStatusResponse)(nil),    // 19: blade.SyncStatusResponse
+	(*HealthResponse)(nil),        // 20: blade.HealthResponse
+	nil,                           // 21: blade.BLADEItem.MetadataEntry
+	nil,                           // 22: blade.BulkIngestionRequest.MetadataEntry
+	nil,                           // 23: blade.IngestionResponse.DetailsEntry
+	nil,                           // 24: blade.BLADEQueryJobRequest.ParametersEntry
+	nil,                           // 25: blade.SyncStatusResponse.ProgressByTypeEntry
+	nil,                           // 26: blade.HealthResponse.ServicesEntry
+	(*structpb.Struct)(nil),       // 27: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil), // 28: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 29: google.protobuf.Empty
 }
 var file_blade_ingestion_proto_depIdxs = []int32{
-	23, // 0: blade.DataSource.config:type_name -> google.protobuf.Struct
+	27, // 0: blade.DataSource.config:type_name -> google.protobuf.Struct
 	1,  // 1: blade.DataSourceList.dataSources:type_name -> blade.DataSource
 	6,  // 2: blade.BLADEQueryResponse.items:type_name -> blade.BLADEItem
-	23, // 3: blade.BLADEItem.data:type_name -> google.protobuf.Struct
-	24, // 4: blade.BLADEItem.lastModified:type_name -> google.protobuf.Timestamp
-	17, // 5: blade.BLADEItem.metadata:type_name -> blade.BLADEItem.MetadataEntry
-	23, // 6: blade.BLADEItemRequest.metadata:type_name -> google.protobuf.Struct
-	18, // 7: blade.BulkIngestionRequest.metadata:type_name -> blade.BulkIngestionRequest.MetadataEntry
-	19, // 8: blade.IngestionResponse.details:type_name -> blade.IngestionResponse.DetailsEntry
-	0,  // 9: blade.SyncJobRequest.syncType:type_name -> blade.SyncJobRequest.SyncType
-	23, // 10: blade.SyncJobRequest.options:type_name -> google.protobuf.Struct
-	20, // 11: blade.BLADEQueryJobRequest.parameters:type_name -> blade.BLADEQueryJobRequest.ParametersEntry
-	23, // 12: blade.BLADEQueryJobRequest.catalogConfig:type_name -> google.protobuf.Struct
-	24, // 13: blade.JobResponse.startTime:type_name -> google.protobuf.Timestamp
-	24, // 14: blade.JobStatusResponse.startTime:type_name -> google.protobuf.Timestamp
-	24, // 15: blade.JobStatusResponse.estimatedCompletion:type_name -> google.protobuf.Timestamp
-	24, // 16: blade.SyncStatusResponse.startTime:type_name -> google.protobuf.Timestamp
-	24, // 17: blade.SyncStatusResponse.estimatedCompletion:type_name -> google.protobuf.Timestamp
-	21, // 18: blade.SyncStatusResponse.progressByType:type_name -> blade.SyncStatusResponse.ProgressByTypeEntry
-	22, // 19: blade.HealthResponse.services:type_name -> blade.HealthResponse.ServicesEntry
-	1,  // 20: blade.BLADEIngestionService.AddBLADESource:input_type -> blade.DataSource
-	25, // 21: blade.BLADEIngestionService.ListBLADESources:input_type -> google.protobuf.Empty
-	2,  // 22: blade.BLADEIngestionService.RemoveBLADESource:input_type -> blade.DataSourceRequest
-	4,  // 23: blade.BLADEIngestionService.QueryBLADE:input_type -> blade.BLADEQuery
-	7,  // 24: blade.BLADEIngestionService.GetBLADEItem:input_type -> blade.BLADEItemRequest
-	7,  // 25: blade.BLADEIngestionService.IngestBLADEItem:input_type -> blade.BLADEItemRequest
-	8,  // 26: blade.BLADEIngestionService.BulkIngestBLADE:input_type -> blade.BulkIngestionRequest
-	10, // 27: blade.BLADEIngestionService.StartBLADESync:input_type -> blade.SyncJobRequest
-	25, // 28: blade.BLADEIngestionService.StopBLADESync:input_type -> google.protobuf.Empty
-	25, // 29: blade.BLADEIngestionService.GetSyncStatus:input_type -> google.protobuf.Empty
-	11, // 30: blade.BLADEIngestionService.StartBLADEQueryJob:input_type -> blade.BLADEQueryJobRequest
-	12, // 31: blade.BLADEIngestionService.GetBLADEQueryJobStatus:input_type -> blade.JobRequest
-	25, // 32: blade.BLADEIngestionService.HealthCheck:input_type -> google.protobuf.Empty
-	25, // 33: blade.BLADEIngestionService.AddBLADESource:output_type -> google.protobuf.Empty
-	3,  // 34: blade.BLADEIngestionService.ListBLADESources:output_type -> blade.DataSourceList
-	25, // 35: blade.BLADEIngestionService.RemoveBLADESource:output_type -> google.protobuf.Empty
-	5,  // 36: blade.BLADEIngestionService.QueryBLADE:output_type -> blade.BLADEQueryResponse
-	6,  // 37: blade.BLADEIngestionService.GetBLADEItem:output_type -> blade.BLADEItem
-	9,  // 38: blade.BLADEIngestionService.IngestBLADEItem:output_type -> blade.IngestionResponse
-	9,  // 39: blade.BLADEIngestionService.BulkIngestBLADE:output_type -> blade.IngestionResponse
-	13, // 40: blade.BLADEIngestionService.StartBLADESync:output_type -> blade.JobResponse
-	13, // 41: blade.BLADEIngestionService.StopBLADESync:output_type -> blade.JobResponse
-	15, // 42: blade.BLADEIngestionService.GetSyncStatus:output_type -> blade.SyncStatusResponse
-	13, // 43: blade.BLADEIngestionService.StartBLADEQueryJob:output_type -> blade.JobResponse
-	14, // 44: blade.BLADEIngestionService.GetBLADEQueryJobStatus:output_type -> blade.JobStatusResponse
-	16, // 45: blade.BLADEIngestionService.HealthCheck:output_type -> blade.HealthResponse
-	33, // [33:46] is the sub-list for method output_type
-	20, // [20:33] is the sub-list for method input_type
-	20, // [20:20] is the sub-list for extension type_name
-	20, // [20:20] is the sub-list for extension extendee
-	0,  // [0:20] is the sub-list for field type_name
+	27, // 3: blade.BLADEItem.data:type_name -> google.protobuf.Struct
+	28, // 4: blade.BLADEItem.lastModified:type_name -> google.protobuf.Timestamp
+	21, // 5: blade.BLADEItem.metadata:type_name -> blade.BLADEItem.MetadataEntry
+	27, // 6: blade.BLADEItemRequest.metadata:type_name -> google.protobuf.Struct
+	22, // 7: blade.BulkIngestionRequest.metadata:type_name -> blade.BulkIngestionRequest.MetadataEntry
+	23, // 8: blade.IngestionResponse.details:type_name -> blade.IngestionResponse.DetailsEntry
+	13, // 9: blade.DeadLetterList.deadLetters:type_name -> blade.DeadLetter
+	28, // 10: blade.DeadLetter.firstFailedAt:type_name -> google.protobuf.Timestamp
+	28, // 11: blade.DeadLetter.lastFailedAt:type_name -> google.protobuf.Timestamp
+	27, // 12: blade.DeadLetter.payload:type_name -> google.protobuf.Struct
+	27, // 13: blade.DeadLetter.metadata:type_name -> google.protobuf.Struct
+	0,  // 14: blade.SyncJobRequest.syncType:type_name -> blade.SyncJobRequest.SyncType
+	27, // 15: blade.SyncJobRequest.options:type_name -> google.protobuf.Struct
+	24, // 16: blade.BLADEQueryJobRequest.parameters:type_name -> blade.BLADEQueryJobRequest.ParametersEntry
+	27, // 17: blade.BLADEQueryJobRequest.catalogConfig:type_name -> google.protobuf.Struct
+	28, // 18: blade.JobResponse.startTime:type_name -> google.protobuf.Timestamp
+	28, // 19: blade.JobStatusResponse.startTime:type_name -> google.protobuf.Timestamp
+	28, // 20: blade.JobStatusResponse.estimatedCompletion:type_name -> google.protobuf.Timestamp
+	28, // 21: blade.SyncStatusResponse.startTime:type_name -> google.protobuf.Timestamp
+	28, // 22: blade.SyncStatusResponse.estimatedCompletion:type_name -> google.protobuf.Timestamp
+	25, // 23: blade.SyncStatusResponse.progressByType:type_name -> blade.SyncStatusResponse.ProgressByTypeEntry
+	26, // 24: blade.HealthResponse.services:type_name -> blade.HealthResponse.ServicesEntry
+	1,  // 25: blade.BLADEIngestionService.AddBLADESource:input_type -> blade.DataSource
+	29, // 26: blade.BLADEIngestionService.ListBLADESources:input_type -> google.protobuf.Empty
+	2,  // 27: blade.BLADEIngestionService.RemoveBLADESource:input_type -> blade.DataSourceRequest
+	4,  // 28: blade.BLADEIngestionService.QueryBLADE:input_type -> blade.BLADEQuery
+	7,  // 29: blade.BLADEIngestionService.GetBLADEItem:input_type -> blade.BLADEItemRequest
+	7,  // 30: blade.BLADEIngestionService.IngestBLADEItem:input_type -> blade.BLADEItemRequest
+	8,  // 31: blade.BLADEIngestionService.BulkIngestBLADE:input_type -> blade.BulkIngestionRequest
+	10, // 32: blade.BLADEIngestionService.ListDeadLetters:input_type -> blade.DeadLetterListRequest
+	12, // 33: blade.BLADEIngestionService.GetDeadLetter:input_type -> blade.DeadLetterRequest
+	12, // 34: blade.BLADEIngestionService.ReplayDeadLetter:input_type -> blade.DeadLetterRequest
+	10, // 35: blade.BLADEIngestionService.ReplayDeadLetters:input_type -> blade.DeadLetterListRequest
+	12, // 36: blade.BLADEIngestionService.DiscardDeadLetter:input_type -> blade.DeadLetterRequest
+	14, // 37: blade.BLADEIngestionService.StartBLADESync:input_type -> blade.SyncJobRequest
+	29, // 38: blade.BLADEIngestionService.StopBLADESync:input_type -> google.protobuf.Empty
+	29, // 39: blade.BLADEIngestionService.GetSyncStatus:input_type -> google.protobuf.Empty
+	15, // 40: blade.BLADEIngestionService.StartBLADEQueryJob:input_type -> blade.BLADEQueryJobRequest
+	16, // 41: blade.BLADEIngestionService.GetBLADEQueryJobStatus:input_type -> blade.JobRequest
+	29, // 42: blade.BLADEIngestionService.HealthCheck:input_type -> google.protobuf.Empty
+	29, // 43: blade.BLADEIngestionService.AddBLADESource:output_type -> google.protobuf.Empty
+	3,  // 44: blade.BLADEIngestionService.ListBLADESources:output_type -> blade.DataSourceList
+	29, // 45: blade.BLADEIngestionService.RemoveBLADESource:output_type -> google.protobuf.Empty
+	5,  // 46: blade.BLADEIngestionService.QueryBLADE:output_type -> blade.BLADEQueryResponse
+	6,  // 47: blade.BLADEIngestionService.GetBLADEItem:output_type -> blade.BLADEItem
+	9,  // 48: blade.BLADEIngestionService.IngestBLADEItem:output_type -> blade.IngestionResponse
+	9,  // 49: blade.BLADEIngestionService.BulkIngestBLADE:output_type -> blade.IngestionResponse
+	11, // 50: blade.BLADEIngestionService.ListDeadLetters:output_type -> blade.DeadLetterList
+	13, // 51: blade.BLADEIngestionService.GetDeadLetter:output_type -> blade.DeadLetter
+	9,  // 52: blade.BLADEIngestionService.ReplayDeadLetter:output_type -> blade.IngestionResponse
+	9,  // 53: blade.BLADEIngestionService.ReplayDeadLetters:output_type -> blade.IngestionResponse
+	29, // 54: blade.BLADEIngestionService.DiscardDeadLetter:output_type -> google.protobuf.Empty
+	17, // 55: blade.BLADEIngestionService.StartBLADESync:output_type -> blade.JobResponse
+	17, // 56: blade.BLADEIngestionService.StopBLADESync:output_type -> blade.JobResponse
+	19, // 57: blade.BLADEIngestionService.GetSyncStatus:output_type -> blade.SyncStatusResponse
+	17, // 58: blade.BLADEIngestionService.StartBLADEQueryJob:output_type -> blade.JobResponse
+	18, // 59: blade.BLADEIngestionService.GetBLADEQueryJobStatus:output_type -> blade.JobStatusResponse
+	20, // 60: blade.BLADEIngestionService.HealthCheck:output_type -> blade.HealthResponse
+	43, // [43:61] is the sub-list for method output_type
+	25, // [25:43] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_blade_ingestion_proto_init() }
@@ -1527,7 +1936,7 @@ func file_blade_ingestion_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_blade_ingestion_proto_rawDesc), len(file_blade_ingestion_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   22,
+			NumMessages:   26,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -27,6 +27,11 @@ const (
 	BLADEIngestionService_GetBLADEItem_FullMethodName           = "/blade.BLADEIngestionService/GetBLADEItem"
 	BLADEIngestionService_IngestBLADEItem_FullMethodName        = "/blade.BLADEIngestionService/IngestBLADEItem"
 	BLADEIngestionService_BulkIngestBLADE_FullMethodName        = "/blade.BLADEIngestionService/BulkIngestBLADE"
+	BLADEIngestionService_ListDeadLetters_FullMethodName        = "/blade.BLADEIngestionService/ListDeadLetters"
+	BLADEIngestionService_GetDeadLetter_FullMethodName          = "/blade.BLADEIngestionService/GetDeadLetter"
+	BLADEIngestionService_ReplayDeadLetter_FullMethodName       = "/blade.BLADEIngestionService/ReplayDeadLetter"
+	BLADEIngestionService_ReplayDeadLetters_FullMethodName      = "/blade.BLADEIngestionService/ReplayDeadLetters"
+	BLADEIngestionService_DiscardDeadLetter_FullMethodName      = "/blade.BLADEIngestionService/DiscardDeadLetter"
 	BLADEIngestionService_StartBLADESync_FullMethodName         = "/blade.BLADEIngestionService/StartBLADESync"
 	BLADEIngestionService_StopBLADESync_FullMethodName          = "/blade.BLADEIngestionService/StopBLADESync"
 	BLADEIngestionService_GetSyncStatus_FullMethodName          = "/blade.BLADEIngestionService/GetSyncStatus"
@@ -53,6 +58,16 @@ type BLADEIngestionServiceClient interface {
 	IngestBLADEItem(ctx context.Context, in *BLADEItemRequest, opts ...grpc.CallOption) (*IngestionResponse, error)
 	// Bulk ingest BLADE items
 	BulkIngestBLADE(ctx context.Context, in *BulkIngestionRequest, opts ...grpc.CallOption) (*IngestionResponse, error)
+	// List dead letters
+	ListDeadLetters(ctx context.Context, in *DeadLetterListRequest, opts ...grpc.CallOption) (*DeadLetterList, error)
+	// Get a dead letter
+	GetDeadLetter(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*DeadLetter, error)
+	// Replay a dead letter
+	ReplayDeadLetter(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*IngestionResponse, error)
+	// Replay matching dead letters
+	ReplayDeadLetters(ctx context.Context, in *DeadLetterListRequest, opts ...grpc.CallOption) (*IngestionResponse, error)
+	// Discard a dead letter
+	DiscardDeadLetter(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Start a BLADE sync job
 	StartBLADESync(ctx context.Context, in *SyncJobRequest, opts ...grpc.CallOption) (*JobResponse, error)
 	// Stop a running sync job
@@ -145,6 +160,56 @@ func (c *bLADEIngestionServiceClient) BulkIngestBLADE(ctx context.Context, in *B
 	return out, nil
 }
 
+func (c *bLADEIngestionServiceClient) ListDeadLetters(ctx context.Context, in *DeadLetterListRequest, opts ...grpc.CallOption) (*DeadLetterList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeadLetterList)
+	err := c.cc.Invoke(ctx, BLADEIngestionService_ListDeadLetters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bLADEIngestionServiceClient) GetDeadLetter(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*DeadLetter, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeadLetter)
+	err := c.cc.Invoke(ctx, BLADEIngestionService_GetDeadLetter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bLADEIngestionServiceClient) ReplayDeadLetter(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*IngestionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestionResponse)
+	err := c.cc.Invoke(ctx, BLADEIngestionService_ReplayDeadLetter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bLADEIngestionServiceClient) ReplayDeadLetters(ctx context.Context, in *DeadLetterListRequest, opts ...grpc.CallOption) (*IngestionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestionResponse)
+	err := c.cc.Invoke(ctx, BLADEIngestionService_ReplayDeadLetters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bLADEIngestionServiceClient) DiscardDeadLetter(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BLADEIngestionService_DiscardDeadLetter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *bLADEIngestionServiceClient) StartBLADESync(ctx context.Context, in *SyncJobRequest, opts ...grpc.CallOption) (*JobResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(JobResponse)
@@ -223,6 +288,16 @@ type BLADEIngestionServiceServer interface {
 	IngestBLADEItem(context.Context, *BLADEItemRequest) (*IngestionResponse, error)
 	// Bulk ingest BLADE items
 	BulkIngestBLADE(context.Context, *BulkIngestionRequest) (*IngestionResponse, error)
+	// List dead letters
+	ListDeadLetters(context.Context, *DeadLetterListRequest) (*DeadLetterList, error)
+	// Get a dead letter
+	GetDeadLetter(context.Context, *DeadLetterRequest) (*DeadLetter, error)
+	// Replay a dead letter
+	ReplayDeadLetter(context.Context, *DeadLetterRequest) (*IngestionResponse, error)
+	// Replay matching dead letters
+	ReplayDeadLetters(context.Context, *DeadLetterListRequest) (*IngestionResponse, error)
+	// Discard a dead letter
+	DiscardDeadLetter(context.Context, *DeadLetterRequest) (*emptypb.Empty, error)
 	// Start a BLADE sync job
 	StartBLADESync(context.Context, *SyncJobRequest) (*JobResponse, error)
 	// Stop a running sync job
@@ -266,6 +341,21 @@ func (UnimplementedBLADEIngestionServiceServer) IngestBLADEItem(context.Context,
 func (UnimplementedBLADEIngestionServiceServer) BulkIngestBLADE(context.Context, *BulkIngestionRequest) (*IngestionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BulkIngestBLADE not implemented")
 }
+func (UnimplementedBLADEIngestionServiceServer) ListDeadLetters(context.Context, *DeadLetterListRequest) (*DeadLetterList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeadLetters not implemented")
+}
+func (UnimplementedBLADEIngestionServiceServer) GetDeadLetter(context.Context, *DeadLetterRequest) (*DeadLetter, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeadLetter not implemented")
+}
+func (UnimplementedBLADEIngestionServiceServer) ReplayDeadLetter(context.Context, *DeadLetterRequest) (*IngestionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayDeadLetter not implemented")
+}
+func (UnimplementedBLADEIngestionServiceServer) ReplayDeadLetters(context.Context, *DeadLetterListRequest) (*IngestionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayDeadLetters not implemented")
+}
+func (UnimplementedBLADEIngestionServiceServer) DiscardDeadLetter(context.Context, *DeadLetterRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscardDeadLetter not implemented")
+}
 func (UnimplementedBLADEIngestionServiceServer) StartBLADESync(context.Context, *SyncJobRequest) (*JobResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StartBLADESync not implemented")
 }
@@ -431,6 +521,96 @@ func _BLADEIngestionService_BulkIngestBLADE_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BLADEIngestionService_ListDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeadLetterListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BLADEIngestionServiceServer).ListDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BLADEIngestionService_ListDeadLetters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BLADEIngestionServiceServer).ListDeadLetters(ctx, req.(*DeadLetterListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BLADEIngestionService_GetDeadLetter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeadLetterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BLADEIngestionServiceServer).GetDeadLetter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BLADEIngestionService_GetDeadLetter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BLADEIngestionServiceServer).GetDeadLetter(ctx, req.(*DeadLetterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BLADEIngestionService_ReplayDeadLetter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeadLetterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BLADEIngestionServiceServer).ReplayDeadLetter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BLADEIngestionService_ReplayDeadLetter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BLADEIngestionServiceServer).ReplayDeadLetter(ctx, req.(*DeadLetterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BLADEIngestionService_ReplayDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeadLetterListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BLADEIngestionServiceServer).ReplayDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BLADEIngestionService_ReplayDeadLetters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BLADEIngestionServiceServer).ReplayDeadLetters(ctx, req.(*DeadLetterListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BLADEIngestionService_DiscardDeadLetter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeadLetterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BLADEIngestionServiceServer).DiscardDeadLetter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BLADEIngestionService_DiscardDeadLetter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BLADEIngestionServiceServer).DiscardDeadLetter(ctx, req.(*DeadLetterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BLADEIngestionService_StartBLADESync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SyncJobRequest)
 	if err := dec(in); err != nil {
@@ -574,6 +754,26 @@ var BLADEIngestionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BulkIngestBLADE",
 			Handler:    _BLADEIngestionService_BulkIngestBLADE_Handler,
 		},
+		{
+			MethodName: "ListDeadLetters",
+			Handler:    _BLADEIngestionService_ListDeadLetters_Handler,
+		},
+		{
+			MethodName: "GetDeadLetter",
+			Handler:    _BLADEIngestionService_GetDeadLetter_Handler,
+		},
+		{
+			MethodName: "ReplayDeadLetter",
+			Handler:    _BLADEIngestionService_ReplayDeadLetter_Handler,
+		},
+		{
+			MethodName: "ReplayDeadLetters",
+			Handler:    _BLADEIngestionService_ReplayDeadLetters_Handler,
+		},
+		{
+			MethodName: "DiscardDeadLetter",
+			Handler:    _BLADEIngestionService_DiscardDeadLetter_Handler,
+		},
 		{
 			MethodName: "StartBLADESync",
 			Handler:    _BLADEIngestionService_StartBLADESync_Handler,
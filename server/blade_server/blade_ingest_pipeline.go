@@ -0,0 +1,375 @@
+package blade_server
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "blade-ingestion-service/database/models"
+
+    "golang.org/x/time/rate"
+)
+
+// ingestPipeline uploads items to their sinks. Items are staged in the
+// outbox as they are added, so an accepted item is delivered even if this
+// process stops; workers only deliver. Items are grouped into batches of
+// CatalogBatchSize, and every upload takes one of the server's
+// ConcurrentUploads slots and draws from its rate limiter, one token per
+// item, so concurrent requests and jobs share both limits. A request
+// pipeline must finish within ProcessingTimeout as a whole; a job pipeline
+// runs until ctx is done, however many items it is given, and only bounds
+// each batch by ProcessingTimeout. Each item's outcome is reported through
+// record, which must be safe for concurrent use.
+//
+//     p := s.newRequestPipeline(ctx, result.record)
+//     for ... {
+//         p.add(item)
+//     }
+//     p.close()
+type ingestPipeline struct {
+    s       *Server
+    ctx     context.Context
+    cancel  context.CancelFunc
+    size    int
+    timeout time.Duration
+
+    // Outlives ctx, so deliveries made before a timeout or stop are saved
+    store context.Context
+
+    pending   []*models.BLADEItem
+    unchanged []string
+    batches   chan []*models.BLADEItem
+    wg        sync.WaitGroup
+    closed    bool
+
+    record  func(itemID string, err error)
+    prepare func(ctx context.Context, item *models.BLADEItem) error
+
+    // Sinks resolved per data source for the life of the pipeline
+    sinksMu sync.Mutex
+    sinks   map[uint][]Sink
+
+    // Catalog request retries made across all uploads
+    retries atomic.Int64
+}
+
+// newUploadLimiter creates the token bucket shared by all uploads; a
+// non-positive rate disables limiting
+func newUploadLimiter(perSecond, burst int) *rate.Limiter {
+    if perSecond <= 0 {
+        return rate.NewLimiter(rate.Inf, 0)
+    }
+    if burst < perSecond {
+        burst = perSecond
+    }
+    return rate.NewLimiter(rate.Limit(perSecond), burst)
+}
+
+// newRequestPipeline starts a pipeline for an RPC. The whole request,
+// including any work the caller does under p.ctx, must finish within
+// ProcessingTimeout; items left by then fail with errProcessingTimeout.
+func (s *Server) newRequestPipeline(ctx context.Context, record func(itemID string, err error)) *ingestPipeline {
+    ctx, cancel := withProcessingTimeout(ctx, s.config.ProcessingTimeout)
+    p := s.startPipeline(ctx, record, s.prepareItem, 0)
+    stop := p.cancel
+    p.cancel = func() {
+        stop()
+        cancel()
+    }
+    return p
+}
+
+// newIngestPipeline starts a pipeline for a long-running job, which bounds
+// each batch by ProcessingTimeout rather than the job. Callers should do
+// all work for the job under p.ctx and must call close.
+func (s *Server) newIngestPipeline(ctx context.Context, record func(itemID string, err error)) *ingestPipeline {
+    return s.startPipeline(ctx, record, s.prepareItem, s.config.ProcessingTimeout)
+}
+
+// newDeliveryPipeline starts a job pipeline for items already staged with
+// an outbox entry, which are sent as they are
+func (s *Server) newDeliveryPipeline(ctx context.Context, record func(itemID string, err error)) *ingestPipeline {
+    return s.startPipeline(ctx, record, func(context.Context, *models.BLADEItem) error { return nil }, s.config.ProcessingTimeout)
+}
+
+func (s *Server) startPipeline(ctx context.Context, record func(itemID string, err error), prepare func(context.Context, *models.BLADEItem) error, batchTimeout time.Duration) *ingestPipeline {
+    ctx, cancel := context.WithCancel(ctx)
+
+    size := s.config.CatalogBatchSize
+    if size < 1 {
+        size = 1
+    }
+    workers := cap(s.uploadSlots)
+    if workers < 1 {
+        workers = 1
+    }
+
+    p := &ingestPipeline{
+        s:       s,
+        ctx:     ctx,
+        cancel:  cancel,
+        size:    size,
+        timeout: batchTimeout,
+        store:   context.WithoutCancel(ctx),
+        batches: make(chan []*models.BLADEItem, workers),
+        record:  record,
+        prepare: prepare,
+        sinks:   make(map[uint][]Sink),
+    }
+    for i := 0; i < workers; i++ {
+        p.wg.Add(1)
+        go func() {
+            defer p.wg.Done()
+            for batch := range p.batches {
+                p.upload(batch)
+            }
+        }()
+    }
+    return p
+}
+
+// newUploadSlots creates the semaphore that caps uploads in flight across
+// all pipelines at ConcurrentUploads
+func newUploadSlots(concurrent int) chan struct{} {
+    if concurrent < 1 {
+        concurrent = 1
+    }
+    return make(chan struct{}, concurrent)
+}
+
+// add stages an item and queues it for delivery, handing a full batch to
+// the workers. Items that cannot be staged are reported right away.
+func (p *ingestPipeline) add(item *models.BLADEItem) {
+    if err := p.prepare(p.ctx, item); err != nil {
+        p.reject(item, err)
+        return
+    }
+    p.pending = append(p.pending, item)
+    if len(p.pending) >= p.size {
+        p.dispatch()
+    }
+}
+
+// reject reports an item that was not staged. Unchanged items leave the
+// dead-letter store; items that fail validation or staging are kept there
+// for replay like those that fail delivery.
+func (p *ingestPipeline) reject(item *models.BLADEItem, err error) {
+    switch {
+    case errors.Is(err, errItemUnchanged):
+        p.unchanged = append(p.unchanged, item.ItemID)
+        if len(p.unchanged) >= p.size {
+            p.clearUnchanged()
+        }
+    case !isInterruption(err):
+        err = p.batchError(p.ctx, err)
+        p.s.recordDeadLetters(p.store, []*models.BLADEItem{item}, []error{err})
+    }
+    p.record(item.ItemID, err)
+}
+
+// clearUnchanged removes the dead letters of items found unchanged
+func (p *ingestPipeline) clearUnchanged() {
+    p.s.clearDeadLetters(p.store, p.unchanged)
+    p.unchanged = nil
+}
+
+// dispatch hands the pending items to a worker, blocking while all are busy
+func (p *ingestPipeline) dispatch() {
+    batch := p.pending
+    p.pending = nil
+    if len(batch) == 0 {
+        return
+    }
+
+    select {
+    case p.batches <- batch:
+    case <-p.ctx.Done():
+        p.skip(batch, context.Cause(p.ctx))
+    }
+}
+
+// close uploads remaining items and waits for the workers to finish
+func (p *ingestPipeline) close() {
+    if p.closed {
+        return
+    }
+    p.closed = true
+    p.clearUnchanged()
+    p.dispatch()
+    close(p.batches)
+    p.wg.Wait()
+    p.cancel()
+}
+
+// errProcessingTimeout fails items that did not reach their sinks within
+// ProcessingTimeout. Unlike items of a stopped job, they count as failed
+// and are retried from the outbox.
+var errProcessingTimeout = errors.New("processing timeout exceeded")
+
+// withProcessingTimeout bounds ctx by timeout, reporting its expiry as
+// errProcessingTimeout; a non-positive timeout only adds cancellation
+func withProcessingTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+    if timeout > 0 {
+        return context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w after %s", errProcessingTimeout, timeout))
+    }
+    return context.WithCancel(ctx)
+}
+
+// batchContext bounds the delivery of one batch by the job's batch timeout
+func (p *ingestPipeline) batchContext() (context.Context, context.CancelFunc) {
+    return withProcessingTimeout(p.ctx, p.timeout)
+}
+
+// batchError reports an error caused by the batch or request running out
+// of time as errProcessingTimeout
+func (p *ingestPipeline) batchError(ctx context.Context, err error) error {
+    if err != nil && ctx.Err() != nil {
+        if cause := context.Cause(ctx); errors.Is(cause, errProcessingTimeout) {
+            return fmt.Errorf("%w: %v", cause, err)
+        }
+    }
+    return err
+}
+
+// upload waits for an upload slot and rate limit tokens and sends each item
+// of a staged batch to its data source's sinks. Only the sink uploads are
+// bounded by a job's batch timeout; bookkeeping for what was sent is saved
+// even if the pipeline stops or times out meanwhile.
+func (p *ingestPipeline) upload(prepared []*models.BLADEItem) {
+    select {
+    case p.s.uploadSlots <- struct{}{}:
+        defer func() { <-p.s.uploadSlots }()
+    case <-p.ctx.Done():
+        p.skip(prepared, context.Cause(p.ctx))
+        return
+    }
+    if err := p.s.uploadLimiter.WaitN(p.ctx, len(prepared)); err != nil {
+        if p.ctx.Err() != nil {
+            err = context.Cause(p.ctx)
+        }
+        p.skip(prepared, err)
+        return
+    }
+    p.s.renewOutbox(p.store, prepared)
+
+    ctx, cancel := p.batchContext()
+    defer cancel()
+
+    errs := make([]error, len(prepared))
+    bySource := make(map[uint][]int)
+    for i, item := range prepared {
+        bySource[item.DataSourceID] = append(bySource[item.DataSourceID], i)
+    }
+    for sourceID, idx := range bySource {
+        items := make([]*models.BLADEItem, len(idx))
+        for j, i := range idx {
+            items[j] = prepared[i]
+        }
+
+        sinks, err := p.sinksFor(sourceID)
+        if err != nil {
+            for _, i := range idx {
+                errs[i] = err
+            }
+            continue
+        }
+
+        // Every sink gets every item it has not accepted yet; an item
+        // fails if any sink rejects it
+        for _, sink := range sinks {
+            var pending []*models.BLADEItem
+            var pendingIdx []int
+            for j, item := range items {
+                if !item.DeliveredTo(sink.Name()) {
+                    pending = append(pending, item)
+                    pendingIdx = append(pendingIdx, idx[j])
+                }
+            }
+            if len(pending) == 0 {
+                continue
+            }
+
+            sinkErrs, retries := sink.Upload(ctx, pending)
+            p.retries.Add(int64(retries))
+            var accepted []*models.BLADEItem
+            for j, i := range pendingIdx {
+                if sinkErrs[j] != nil {
+                    if errs[i] == nil {
+                        errs[i] = fmt.Errorf("%s sink: %w", sink.Name(), p.batchError(ctx, sinkErrs[j]))
+                    }
+                    continue
+                }
+                if err := pending[j].SetDeliveredTo(sink.Name()); err != nil && errs[i] == nil {
+                    errs[i] = err
+                }
+                accepted = append(accepted, pending[j])
+            }
+
+            // Saved right away, so a retry after another sink fails neither
+            // sends to this sink again nor creates a second catalog entry
+            if err := p.s.recordDelivery(p.store, accepted); err != nil {
+                for j, i := range pendingIdx {
+                    if sinkErrs[j] == nil && errs[i] == nil {
+                        errs[i] = err
+                    }
+                }
+            }
+        }
+    }
+
+    var uploaded []*models.BLADEItem
+    for i, item := range prepared {
+        if errs[i] == nil {
+            uploaded = append(uploaded, item)
+        }
+    }
+    if err := p.s.markUploaded(p.store, uploaded...); err != nil {
+        for i := range prepared {
+            if errs[i] == nil {
+                errs[i] = err
+            }
+        }
+    }
+
+    // Failed items are kept for replay and retried from the outbox;
+    // delivered ones leave the dead-letter store
+    p.s.recordDeadLetters(p.store, prepared, errs)
+    p.s.failOutbox(p.store, prepared, errs)
+    var delivered []string
+    for i, item := range prepared {
+        if errs[i] == nil {
+            delivered = append(delivered, item.ItemID)
+        }
+    }
+    p.s.clearDeadLetters(p.store, delivered)
+
+    for i, item := range prepared {
+        p.record(item.ItemID, errs[i])
+    }
+}
+
+// sinksFor resolves a data source's sinks once per pipeline
+func (p *ingestPipeline) sinksFor(sourceID uint) ([]Sink, error) {
+    p.sinksMu.Lock()
+    defer p.sinksMu.Unlock()
+
+    if sinks, ok := p.sinks[sourceID]; ok {
+        return sinks, nil
+    }
+    sinks, err := p.s.sinksFor(p.ctx, sourceID)
+    if err != nil {
+        return nil, err
+    }
+    p.sinks[sourceID] = sinks
+    return sinks, nil
+}
+
+// skip reports items that were never uploaded
+func (p *ingestPipeline) skip(items []*models.BLADEItem, err error) {
+    for _, item := range items {
+        p.record(item.ItemID, fmt.Errorf("upload skipped: %w", err))
+    }
+}
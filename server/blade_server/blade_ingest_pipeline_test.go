@@ -0,0 +1,323 @@
+package blade_server
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "golang.org/x/time/rate"
+    "gorm.io/datatypes"
+)
+
+// fakeSink records uploads, optionally taking delay per batch and failing
+// the listed items
+type fakeSink struct {
+    name  string
+    delay func(call int) time.Duration
+    fail  map[string]error
+
+    mu       sync.Mutex
+    calls    int
+    uploaded []string
+
+    active    atomic.Int32
+    maxActive atomic.Int32
+}
+
+func (f *fakeSink) Name() string { return f.name }
+
+func (f *fakeSink) Upload(ctx context.Context, items []*models.BLADEItem) ([]error, int) {
+    n := f.active.Add(1)
+    defer f.active.Add(-1)
+    for {
+        max := f.maxActive.Load()
+        if n <= max || f.maxActive.CompareAndSwap(max, n) {
+            break
+        }
+    }
+
+    f.mu.Lock()
+    f.calls++
+    call := f.calls
+    f.mu.Unlock()
+
+    errs := make([]error, len(items))
+    if f.delay != nil {
+        select {
+        case <-time.After(f.delay(call)):
+        case <-ctx.Done():
+            for i := range errs {
+                errs[i] = ctx.Err()
+            }
+            return errs, 0
+        }
+    }
+
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    for i, item := range items {
+        if err := f.fail[item.ItemID]; err != nil {
+            errs[i] = err
+            continue
+        }
+        f.uploaded = append(f.uploaded, item.ItemID)
+    }
+    return errs, 0
+}
+
+func (f *fakeSink) Retract(context.Context, *models.BLADEItem) error { return nil }
+
+// outcomes collects the results a pipeline records
+type outcomes struct {
+    mu   sync.Mutex
+    errs map[string]error
+}
+
+func (o *outcomes) record(itemID string, err error) {
+    o.mu.Lock()
+    defer o.mu.Unlock()
+    if o.errs == nil {
+        o.errs = make(map[string]error)
+    }
+    o.errs[itemID] = err
+}
+
+// runPipeline delivers n maintenance items to sinks
+func runPipeline(s *Server, n int, sinks ...Sink) *outcomes {
+    result := &outcomes{}
+    p := s.newDeliveryPipeline(context.Background(), result.record)
+    p.sinks[0] = sinks
+    for i := 0; i < n; i++ {
+        p.add(&models.BLADEItem{ItemID: fmt.Sprintf("M-%02d", i), DataType: "maintenance"})
+    }
+    p.close()
+    return result
+}
+
+func TestPipelineDrainsWorkersOnClose(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{CatalogBatchSize: 2, ConcurrentUploads: 3})
+    sink := &fakeSink{name: "fake", delay: func(int) time.Duration { return 20 * time.Millisecond }}
+
+    result := runPipeline(s, 11, sink)
+
+    // close returned only after every batch, including the partial one, was sent
+    assert.Len(t, sink.uploaded, 11)
+    assert.Len(t, result.errs, 11)
+    for itemID, err := range result.errs {
+        assert.NoError(t, err, itemID)
+    }
+    assert.LessOrEqual(t, sink.maxActive.Load(), int32(3))
+    assert.Equal(t, 6, sink.calls)
+}
+
+func TestPipelineReportsSinkFailuresPerItem(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{CatalogBatchSize: 5, ConcurrentUploads: 2})
+    sink := &fakeSink{name: "fake", fail: map[string]error{"M-03": errors.New("rejected")}}
+
+    result := runPipeline(s, 5, sink)
+
+    assert.EqualError(t, result.errs["M-03"], "fake sink: rejected")
+    for _, itemID := range []string{"M-00", "M-01", "M-02", "M-04"} {
+        assert.NoError(t, result.errs[itemID])
+    }
+}
+
+func TestPipelineRateLimitsUploads(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{CatalogBatchSize: 5, ConcurrentUploads: 4})
+    s.uploadLimiter = rate.NewLimiter(100, 5)
+    sink := &fakeSink{name: "fake"}
+
+    // The first batch uses the burst; the other four wait 50ms each
+    start := time.Now()
+    runPipeline(s, 25, sink)
+
+    assert.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+    assert.Len(t, sink.uploaded, 25)
+}
+
+func TestNewUploadLimiter(t *testing.T) {
+    limiter := newUploadLimiter(10, 50)
+    assert.Equal(t, rate.Limit(10), limiter.Limit())
+    assert.Equal(t, 50, limiter.Burst())
+
+    // A batch always fits the burst
+    assert.Equal(t, 10, newUploadLimiter(10, 5).Burst())
+
+    assert.Equal(t, rate.Inf, newUploadLimiter(0, 5).Limit())
+}
+
+func TestPipelineTimesOutBatchesNotOperations(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{
+        CatalogBatchSize:  2,
+        ConcurrentUploads: 1,
+        ProcessingTimeout: 50 * time.Millisecond,
+    })
+
+    // The second batch hangs; the others take 20ms, so the whole operation
+    // runs well past ProcessingTimeout
+    sink := &fakeSink{name: "fake", delay: func(call int) time.Duration {
+        if call == 2 {
+            return time.Hour
+        }
+        return 20 * time.Millisecond
+    }}
+
+    start := time.Now()
+    result := runPipeline(s, 12, sink)
+    assert.Greater(t, time.Since(start), 150*time.Millisecond)
+
+    for _, itemID := range []string{"M-02", "M-03"} {
+        err := result.errs[itemID]
+        assert.ErrorIs(t, err, errProcessingTimeout)
+        assert.False(t, isInterruption(err), "a timed out batch is a failure, not an interruption")
+    }
+    assert.Len(t, sink.uploaded, 10)
+    for itemID, err := range result.errs {
+        if itemID != "M-02" && itemID != "M-03" {
+            assert.NoError(t, err, itemID)
+        }
+    }
+}
+
+func TestRequestPipelineTimesOutTheWholeRequest(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{
+        CatalogBatchSize:  2,
+        ConcurrentUploads: 1,
+        ProcessingTimeout: 50 * time.Millisecond,
+    })
+
+    // No batch takes long, but together they run past ProcessingTimeout
+    sink := &fakeSink{name: "fake", delay: func(int) time.Duration { return 20 * time.Millisecond }}
+    result := &outcomes{}
+    p := s.newRequestPipeline(context.Background(), result.record)
+    p.sinks[0] = []Sink{sink}
+    for i := 0; i < 12; i++ {
+        p.add(&models.BLADEItem{ItemID: fmt.Sprintf("M-%02d", i), DataType: "maintenance"})
+    }
+    p.close()
+
+    assert.Len(t, result.errs, 12)
+    assert.NotEmpty(t, sink.uploaded)
+    assert.Less(t, len(sink.uploaded), 12)
+    for _, itemID := range sink.uploaded {
+        assert.NoError(t, result.errs[itemID], itemID)
+    }
+    assert.ErrorIs(t, result.errs["M-11"], errProcessingTimeout)
+    assert.False(t, isInterruption(result.errs["M-11"]))
+}
+
+func TestPipelinesShareUploadSlots(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{CatalogBatchSize: 1, ConcurrentUploads: 2})
+    sink := &fakeSink{name: "fake", delay: func(int) time.Duration { return 10 * time.Millisecond }}
+
+    var wg sync.WaitGroup
+    for i := 0; i < 3; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            runPipeline(s, 6, sink)
+        }()
+    }
+    wg.Wait()
+
+    assert.Len(t, sink.uploaded, 18)
+    assert.Equal(t, int32(2), sink.maxActive.Load(), "three pipelines must not exceed ConcurrentUploads together")
+}
+
+func TestPipelineDeliversToEachItemsSourceSinks(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{CatalogBatchSize: 4, ConcurrentUploads: 1})
+    current := &fakeSink{name: "current"}
+    archive := &fakeSink{name: "archive"}
+
+    result := &outcomes{}
+    p := s.newDeliveryPipeline(context.Background(), result.record)
+    p.sinks[1] = []Sink{current}
+    p.sinks[2] = []Sink{archive}
+    p.add(&models.BLADEItem{ItemID: "M-00", DataType: "maintenance", DataSourceID: 1})
+    p.add(&models.BLADEItem{ItemID: "M-01", DataType: "maintenance", DataSourceID: 2})
+    p.add(&models.BLADEItem{ItemID: "M-02", DataType: "maintenance", DataSourceID: 1})
+    p.close()
+
+    for _, err := range result.errs {
+        assert.NoError(t, err)
+    }
+    assert.ElementsMatch(t, []string{"M-00", "M-02"}, current.uploaded)
+    assert.Equal(t, []string{"M-01"}, archive.uploaded)
+}
+
+func TestPipelineCancellationInterruptsItems(t *testing.T) {
+    s, _ := newRecordingServer(t, &utils.Config{CatalogBatchSize: 2, ConcurrentUploads: 1})
+    sink := &fakeSink{name: "fake", delay: func(int) time.Duration { return time.Hour }}
+
+    ctx, cancel := context.WithCancel(context.Background())
+    result := &outcomes{}
+    p := s.newDeliveryPipeline(ctx, result.record)
+    p.sinks[0] = []Sink{sink}
+    p.add(&models.BLADEItem{ItemID: "M-00", DataType: "maintenance"})
+    p.add(&models.BLADEItem{ItemID: "M-01", DataType: "maintenance"})
+
+    time.AfterFunc(20*time.Millisecond, cancel)
+    p.close()
+
+    assert.Len(t, result.errs, 2)
+    for _, err := range result.errs {
+        assert.True(t, isInterruption(err))
+    }
+}
+
+func TestPipelineRetryOnlyResendsToFailedSinks(t *testing.T) {
+    var creates atomic.Int32
+    catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/catalog/item" || r.Method != http.MethodPost {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        creates.Add(1)
+        w.Write([]byte(`{"catalog_id":"cat-1"}`))
+    }))
+    defer catalog.Close()
+
+    s, rec := newRecordingServer(t, &utils.Config{CatalogURL: catalog.URL, CatalogBatchSize: 1, ConcurrentUploads: 1})
+    failing := &fakeSink{name: "fake", fail: map[string]error{"M-00": errors.New("unavailable")}}
+    sinks := []Sink{&catalogSink{uploader: s.uploader}, failing}
+
+    item := &models.BLADEItem{ItemID: "M-00", DataType: "maintenance", ContentHash: "h1", Data: []byte(`{}`)}
+    result := &outcomes{}
+    p := s.newDeliveryPipeline(context.Background(), result.record)
+    p.sinks[0] = sinks
+    p.add(item)
+    p.close()
+    assert.ErrorContains(t, result.errs["M-00"], "fake sink: unavailable")
+    assert.Equal(t, int32(1), creates.Load())
+
+    // The catalog ID and the catalog's acceptance were saved despite the failure
+    updates := rec.matching(`UPDATE "blade_items" SET "catalog_id"=`, `"delivered_sinks"=`)
+    require.Len(t, updates, 1)
+    assert.Equal(t, "cat-1", updates[0].Args[0])
+
+    // A retry loads the item as saved and only sends it to the failed sink
+    delete(failing.fail, "M-00")
+    retried := &models.BLADEItem{ItemID: "M-00", DataType: "maintenance", ContentHash: "h1", Data: []byte(`{}`)}
+    retried.CatalogID = updates[0].Args[0].(string)
+    retried.DeliveredSinks = datatypes.JSON(updates[0].Args[1].(string))
+
+    result = &outcomes{}
+    p = s.newDeliveryPipeline(context.Background(), result.record)
+    p.sinks[0] = sinks
+    p.add(retried)
+    p.close()
+    assert.NoError(t, result.errs["M-00"])
+    assert.Equal(t, int32(1), creates.Load(), "the retry must not create a second catalog entry")
+    assert.Equal(t, []string{"M-00"}, failing.uploaded)
+}
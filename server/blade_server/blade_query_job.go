@@ -0,0 +1,670 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "slices"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    pb "blade-ingestion-service/generated/proto"
+
+    "github.com/google/uuid"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/timestamppb"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Job status values
+const (
+    JobStatusPending   = "PENDING"
+    JobStatusRunning   = "RUNNING"
+    JobStatusCompleted = "COMPLETED"
+    JobStatusFailed    = "FAILED"
+    JobStatusCancelled = "CANCELLED"
+)
+
+// maxRecentErrors caps the number of errors kept on a job
+const maxRecentErrors = 10
+
+const (
+    // jobPollInterval is how often idle workers look for pending jobs
+    jobPollInterval = 5 * time.Second
+
+    // jobFlushInterval is how often running jobs persist their progress
+    jobFlushInterval = time.Second
+)
+
+// jobProgress tracks counters shared by sync and query jobs
+type jobProgress struct {
+    mu               sync.RWMutex
+    status           string
+    currentOperation string
+    totalItems       int32
+    processedItems   int32
+    successCount     int32
+    errorCount       int32
+    skippedCount     int32
+    startTime        time.Time
+    endTime          *time.Time
+    recentErrors     []string
+}
+
+func newJobProgress() jobProgress {
+    return jobProgress{
+        status:    JobStatusRunning,
+        startTime: time.Now(),
+    }
+}
+
+// setOperation updates the current operation description
+func (p *jobProgress) setOperation(format string, args ...interface{}) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.currentOperation = fmt.Sprintf(format, args...)
+}
+
+// addTotal increases the number of items the job expects to process
+func (p *jobProgress) addTotal(n int) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.totalItems += int32(n)
+}
+
+// recordItem records the outcome of processing one item. Unchanged items
+// count as processed but neither succeeded nor failed; interrupted items are
+// not counted, since a resumed job processes them again.
+func (p *jobProgress) recordItem(itemID string, err error) {
+    if isInterruption(err) {
+        return
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.processedItems++
+    if errors.Is(err, errItemUnchanged) {
+        p.skippedCount++
+        return
+    }
+    if err != nil {
+        p.errorCount++
+        p.addErrorLocked(fmt.Sprintf("%s: %v", itemID, err))
+        return
+    }
+    p.successCount++
+}
+
+// addError records a job-level error
+func (p *jobProgress) addError(err error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.addErrorLocked(err.Error())
+}
+
+func (p *jobProgress) addErrorLocked(msg string) {
+    p.recentErrors = append(p.recentErrors, msg)
+    if len(p.recentErrors) > maxRecentErrors {
+        p.recentErrors = p.recentErrors[len(p.recentErrors)-maxRecentErrors:]
+    }
+}
+
+// finish marks the job as done with the given status
+func (p *jobProgress) finish(status string) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    now := time.Now()
+    p.status = status
+    p.endTime = &now
+    p.currentOperation = strings.ToLower(status)
+}
+
+// isRunning reports whether the job is still running
+func (p *jobProgress) isRunning() bool {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+    return p.status == JobStatusRunning || p.status == JobStatusPending
+}
+
+// counts returns the success and error counters
+func (p *jobProgress) counts() (int32, int32) {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+    return p.successCount, p.errorCount
+}
+
+// progressLocked returns completion as a percentage
+func (p *jobProgress) progressLocked() float32 {
+    if p.totalItems == 0 {
+        if p.status == JobStatusCompleted {
+            return 100
+        }
+        return 0
+    }
+    return float32(p.processedItems) / float32(p.totalItems) * 100
+}
+
+// estimatedCompletionLocked extrapolates an ETA from the processing rate
+func (p *jobProgress) estimatedCompletionLocked() *timestamppb.Timestamp {
+    return estimateCompletion(p.startTime, p.endTime, int(p.processedItems), int(p.totalItems))
+}
+
+// estimateCompletion extrapolates an ETA from the average time per item
+func estimateCompletion(start time.Time, end *time.Time, processed, total int) *timestamppb.Timestamp {
+    if end != nil {
+        return timestamppb.New(*end)
+    }
+    if processed == 0 || total == 0 || start.IsZero() {
+        return nil
+    }
+    perItem := time.Since(start) / time.Duration(processed)
+    remaining := time.Duration(total-processed) * perItem
+    return timestamppb.New(time.Now().Add(remaining))
+}
+
+// snapshot copies the job's counters onto its persisted model
+func (p *jobProgress) snapshot(job *models.IngestionJob) {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+
+    job.Status = p.status
+    job.CurrentOperation = p.currentOperation
+    job.TotalItems = int(p.totalItems)
+    job.ProcessedItems = int(p.processedItems)
+    job.SuccessCount = int(p.successCount)
+    job.ErrorCount = int(p.errorCount)
+    job.SkippedCount = int(p.skippedCount)
+    job.CompletedAt = p.endTime
+    if data, err := json.Marshal(p.recentErrors); err == nil {
+        job.RecentErrors = data
+    }
+}
+
+// queryJobParameters is the persisted request configuration of a query job
+type queryJobParameters struct {
+    // CatalogConfig holds classification and metadata applied on upload
+    CatalogConfig map[string]interface{} `json:"catalog_config,omitempty"`
+
+    // QueryParameters are bound to :name markers in the SQL query
+    QueryParameters map[string]string `json:"query_parameters,omitempty"`
+}
+
+// statementParameters returns the query parameters in a stable order
+func (p queryJobParameters) statementParameters() []StatementParameter {
+    names := make([]string, 0, len(p.QueryParameters))
+    for name := range p.QueryParameters {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    params := make([]StatementParameter, 0, len(names))
+    for _, name := range names {
+        params = append(params, NewParameter(name, p.QueryParameters[name]))
+    }
+    return params
+}
+
+// QueryJob is a query job currently running on this instance
+type QueryJob struct {
+    jobProgress
+    queryJobParameters
+
+    ID        string
+    DataType  string
+    SQLQuery  string
+    cancel    context.CancelFunc
+    cursor    *progressCursor
+    lastFlush time.Time
+}
+
+// keyedQuery wraps the job's query to read its rows in item ID order, after
+// the given position when resuming, so a checkpoint can name the last row
+// processed whatever order the query itself returns
+func (j *QueryJob) keyedQuery(after *rowPosition) (string, []StatementParameter) {
+    query := fmt.Sprintf("SELECT * FROM (%s) AS job_rows", trimStatement(j.SQLQuery))
+    params := j.statementParameters()
+    if after != nil {
+        query += " WHERE item_id > :resume_item"
+        params = append(params, NewParameter("resume_item", after.ItemID))
+    }
+    return query + " ORDER BY item_id ASC", params
+}
+
+// trimStatement strips surrounding space and trailing semicolons from a
+// query, so it can be wrapped as a subquery
+func trimStatement(query string) string {
+    return strings.TrimRight(strings.TrimSpace(query), ";")
+}
+
+// checkQueryColumns runs a query job's SQL for its columns alone, and
+// rejects queries that do not return the item_id column their rows are read
+// and resumed in order of
+func (s *Server) checkQueryColumns(ctx context.Context, sqlQuery string, params []StatementParameter) error {
+    query := fmt.Sprintf("SELECT * FROM (%s) AS job_rows LIMIT 0", trimStatement(sqlQuery))
+    it, err := s.databricks.StreamQuery(ctx, query, params...)
+    var stmtErr *StatementError
+    if errors.As(err, &stmtErr) {
+        return status.Errorf(codes.InvalidArgument, "invalid sqlQuery: %v", err)
+    }
+    if err != nil {
+        return status.Errorf(codes.Internal, "query failed: %v", err)
+    }
+    defer it.Close()
+
+    if !slices.Contains(it.Columns(), "item_id") {
+        return status.Error(codes.InvalidArgument, "invalid sqlQuery: it must return an item_id column")
+    }
+    return nil
+}
+
+// StartBLADEQueryJob queues an asynchronous query and ingest job
+func (s *Server) StartBLADEQueryJob(ctx context.Context, req *pb.BLADEQueryJobRequest) (*pb.JobResponse, error) {
+    if strings.TrimSpace(req.GetSqlQuery()) == "" {
+        return nil, status.Error(codes.InvalidArgument, "sqlQuery is required")
+    }
+    if !s.config.IsBLADEDataType(req.GetDataType()) {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid data type: %s", req.GetDataType())
+    }
+
+    jobParams := queryJobParameters{
+        CatalogConfig:   req.GetCatalogConfig().AsMap(),
+        QueryParameters: req.GetParameters(),
+    }
+    if err := validateParameters(jobParams.statementParameters()); err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid query parameters: %v", err)
+    }
+    if _, ok := jobParams.QueryParameters["resume_item"]; ok {
+        return nil, status.Error(codes.InvalidArgument, "invalid query parameters: resume_item is reserved")
+    }
+    if err := s.checkQueryColumns(ctx, req.GetSqlQuery(), jobParams.statementParameters()); err != nil {
+        return nil, err
+    }
+    params, err := json.Marshal(jobParams)
+    if err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid catalog config: %v", err)
+    }
+
+    record := &models.IngestionJob{
+        JobID:            uuid.New().String(),
+        JobType:          models.JobTypeQuery,
+        Status:           JobStatusPending,
+        DataType:         req.GetDataType(),
+        SQLQuery:         req.GetSqlQuery(),
+        Parameters:       params,
+        CurrentOperation: "queued",
+    }
+    if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to create job: %v", err)
+    }
+
+    // Wake an idle worker without blocking if one is already signalled
+    select {
+    case s.jobWakeup <- struct{}{}:
+    default:
+    }
+
+    return &pb.JobResponse{
+        JobId:     record.JobID,
+        Status:    JobStatusPending,
+        Message:   fmt.Sprintf("Query job queued for %s data", record.DataType),
+        StartTime: timestamppb.New(record.CreatedAt),
+    }, nil
+}
+
+// GetBLADEQueryJobStatus returns the persisted status of a query job
+func (s *Server) GetBLADEQueryJobStatus(ctx context.Context, req *pb.JobRequest) (*pb.JobStatusResponse, error) {
+    if req.GetJobId() == "" {
+        return nil, status.Error(codes.InvalidArgument, "jobId is required")
+    }
+
+    var record models.IngestionJob
+    err := s.db.WithContext(ctx).
+        Where("job_id = ? AND job_type = ?", req.GetJobId(), models.JobTypeQuery).
+        First(&record).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, status.Errorf(codes.NotFound, "job %s not found", req.GetJobId())
+    }
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to load job: %v", err)
+    }
+
+    recentErrors, err := decodeRecentErrors(&record)
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to load job: %v", err)
+    }
+
+    var progress float32
+    if record.TotalItems > 0 {
+        progress = float32(record.ProcessedItems) / float32(record.TotalItems) * 100
+    } else if record.Status == JobStatusCompleted {
+        progress = 100
+    }
+
+    resp := &pb.JobStatusResponse{
+        JobId:            record.JobID,
+        Status:           record.Status,
+        Progress:         progress,
+        CurrentOperation: record.CurrentOperation,
+        TotalItems:       int32(record.TotalItems),
+        ProcessedItems:   int32(record.ProcessedItems),
+        SuccessCount:     int32(record.SuccessCount),
+        ErrorCount:       int32(record.ErrorCount),
+        RecentErrors:     recentErrors,
+    }
+    if record.StartedAt != nil {
+        resp.StartTime = timestamppb.New(*record.StartedAt)
+        resp.EstimatedCompletion = estimateCompletion(*record.StartedAt, record.CompletedAt,
+            record.ProcessedItems, record.TotalItems)
+    }
+
+    return resp, nil
+}
+
+// startQueryWorkers launches the bounded pool of query job workers.
+// Workers stop claiming new jobs once ctx is done.
+func (s *Server) startQueryWorkers(ctx context.Context) {
+    workers := s.config.QueryJobWorkers
+    if workers <= 0 {
+        workers = 1
+    }
+
+    for i := 0; i < workers; i++ {
+        s.jobsWG.Add(1)
+        go func() {
+            defer s.jobsWG.Done()
+            s.queryWorker(ctx)
+        }()
+    }
+    log.Printf("Started %d query job workers", workers)
+}
+
+// queryWorker claims and runs pending query jobs until ctx is done
+func (s *Server) queryWorker(ctx context.Context) {
+    ticker := time.NewTicker(jobPollInterval)
+    defer ticker.Stop()
+
+    for {
+        for ctx.Err() == nil {
+            record, err := s.claimQueryJob(ctx)
+            if err != nil {
+                log.Printf("Failed to claim query job: %v", err)
+                break
+            }
+            if record == nil {
+                break
+            }
+            s.runQueryJob(record)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-s.jobWakeup:
+        case <-ticker.C:
+        }
+    }
+}
+
+// claimQueryJob atomically moves the oldest pending query job to RUNNING
+func (s *Server) claimQueryJob(ctx context.Context) (*models.IngestionJob, error) {
+    var record models.IngestionJob
+    err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+            Where("job_type = ? AND status = ?", models.JobTypeQuery, JobStatusPending).
+            Order("id").
+            First(&record).Error
+        if err != nil {
+            return err
+        }
+
+        // Resumed jobs keep their original start time
+        now := time.Now()
+        record.Status = JobStatusRunning
+        if record.StartedAt == nil {
+            record.StartedAt = &now
+        }
+        return tx.Model(&record).Updates(map[string]interface{}{
+            "status":     record.Status,
+            "started_at": record.StartedAt,
+        }).Error
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &record, nil
+}
+
+// recoverQueryJobs requeues query jobs left RUNNING by a previous process
+// to resume from their checkpoints. Jobs already resumed JobMaxResumes times
+// are marked failed instead.
+func (s *Server) recoverQueryJobs() error {
+    result := s.db.Model(&models.IngestionJob{}).
+        Where("job_type = ? AND status = ? AND resume_count < ?", models.JobTypeQuery, JobStatusRunning, s.config.JobMaxResumes).
+        Updates(map[string]interface{}{
+            "status":            JobStatusPending,
+            "current_operation": "resuming",
+            "resume_count":      gorm.Expr("resume_count + 1"),
+        })
+    if result.Error != nil {
+        return fmt.Errorf("failed to recover query jobs: %w", result.Error)
+    }
+    if result.RowsAffected > 0 {
+        log.Printf("Requeued %d interrupted query jobs to resume", result.RowsAffected)
+    }
+    return s.failInterruptedJobs(models.JobTypeQuery, "")
+}
+
+// failInterruptedJobs fails jobs of a type left RUNNING by a previous
+// process, except the one with keepJobID
+func (s *Server) failInterruptedJobs(jobType, keepJobID string) error {
+    errorsJSON, _ := json.Marshal([]string{"interrupted by service restart"})
+    now := time.Now()
+
+    result := s.db.Model(&models.IngestionJob{}).
+        Where("job_type = ? AND status = ? AND job_id <> ?", jobType, JobStatusRunning, keepJobID).
+        Updates(map[string]interface{}{
+            "status":            JobStatusFailed,
+            "current_operation": "interrupted",
+            "recent_errors":     errorsJSON,
+            "completed_at":      now,
+        })
+    if result.Error != nil {
+        return fmt.Errorf("failed to recover %s jobs: %w", jobType, result.Error)
+    }
+    if result.RowsAffected > 0 {
+        log.Printf("Marked %d interrupted %s jobs as failed", result.RowsAffected, jobType)
+    }
+    return nil
+}
+
+// runQueryJob executes a claimed job's query and ingests each resulting row
+func (s *Server) runQueryJob(record *models.IngestionJob) {
+    job := &QueryJob{
+        jobProgress: newJobProgress(),
+        ID:          record.JobID,
+        DataType:    record.DataType,
+        SQLQuery:    record.SQLQuery,
+    }
+    cp, err := job.resume(record)
+    if err != nil {
+        s.failUnresumableJob(record, err)
+        return
+    }
+
+    jobCtx, cancel := context.WithCancel(context.Background())
+    job.cancel = cancel
+
+    s.jobsMu.Lock()
+    s.queryJobs[job.ID] = job
+    s.jobsMu.Unlock()
+
+    defer func() {
+        cancel()
+        s.jobsMu.Lock()
+        delete(s.queryJobs, job.ID)
+        s.jobsMu.Unlock()
+        s.flushQueryJob(job, record)
+    }()
+
+    s.executeQueryJob(jobCtx, job, record, cp)
+}
+
+// failUnresumableJob fails a job whose stored state cannot be decoded.
+// Starting it over would upload every row again, so its counters and
+// checkpoint are left as they were.
+func (s *Server) failUnresumableJob(record *models.IngestionJob, err error) {
+    log.Printf("Cannot resume %s job %s: %v", record.JobType, record.JobID, err)
+    errorsJSON, _ := json.Marshal([]string{fmt.Sprintf("cannot resume job: %v", err)})
+    now := time.Now()
+
+    err = s.db.Model(record).Updates(map[string]interface{}{
+        "status":            JobStatusFailed,
+        "current_operation": "failed",
+        "recent_errors":     errorsJSON,
+        "completed_at":      now,
+    }).Error
+    if err != nil {
+        log.Printf("Failed to persist failure of %s job %s: %v", record.JobType, record.JobID, err)
+    }
+}
+
+// resume restores a claimed job's parameters, and the checkpoint and
+// counters of an interrupted run
+func (j *QueryJob) resume(record *models.IngestionJob) (jobCheckpoint, error) {
+    if len(record.Parameters) > 0 {
+        if err := json.Unmarshal(record.Parameters, &j.queryJobParameters); err != nil {
+            return jobCheckpoint{}, fmt.Errorf("invalid parameters: %w", err)
+        }
+    }
+    cp, err := loadCheckpoint(record)
+    if err != nil {
+        return jobCheckpoint{}, err
+    }
+    if err := j.restore(record, cp.queryCounts()); err != nil {
+        return jobCheckpoint{}, err
+    }
+    return cp, nil
+}
+
+// executeQueryJob runs the query and uploads rows, recording progress on job.
+// Rows are read in item ID order, so a resumed job reads on after the last
+// row its checkpoint covers.
+func (s *Server) executeQueryJob(ctx context.Context, job *QueryJob, record *models.IngestionJob, cp jobCheckpoint) {
+    job.setOperation("executing query")
+    s.flushQueryJob(job, record)
+
+    job.cursor = newProgressCursor(cp.After, cp.queryCounts())
+    pipeline := s.newIngestPipeline(ctx, func(itemID string, err error) {
+        job.recordItem(itemID, err)
+        // Interrupted items are read again on resume
+        if !isInterruption(err) {
+            job.cursor.complete(itemID, itemOutcome(err))
+        }
+    })
+    defer pipeline.close()
+
+    query, params := job.keyedQuery(cp.After)
+    it, err := s.databricks.StreamQuery(pipeline.ctx, query, params...)
+    if err != nil {
+        job.addError(fmt.Errorf("query failed: %w", err))
+        job.finish(JobStatusFailed)
+        log.Printf("Query job %s failed: %v", job.ID, err)
+        return
+    }
+    defer it.Close()
+    job.addTotal(int(it.TotalRows()))
+
+    classification, _ := job.CatalogConfig["classification"].(string)
+
+    if cp.After != nil {
+        job.setOperation("uploading items after %s", cp.After.ItemID)
+    } else {
+        job.setOperation("uploading items")
+    }
+    for it.Next() && pipeline.ctx.Err() == nil {
+        row, err := it.Row()
+        if err != nil {
+            job.recordItem("", err)
+            job.cursor.skip(nil, itemOutcome(err))
+            continue
+        }
+
+        // Rows without an item ID sort first and are read again until a
+        // row with one is checkpointed
+        var pos *rowPosition
+        if itemID, ok := row["item_id"].(string); ok && itemID != "" {
+            pos = &rowPosition{ItemID: itemID}
+        }
+        item, err := TransformToBLADEItem(job.DataType, row)
+        if err != nil {
+            job.recordItem("", err)
+            job.cursor.skip(pos, itemOutcome(err))
+            continue
+        }
+        item.IngestionJobID = job.ID
+        if classification != "" {
+            item.ClassificationMarking = classification
+        }
+        if len(job.CatalogConfig) > 0 {
+            if err := mergeMetadata(item, job.CatalogConfig); err != nil {
+                job.recordItem(item.ItemID, err)
+                job.cursor.skip(pos, itemOutcome(err))
+                continue
+            }
+        }
+
+        job.cursor.add(item.ItemID, pos)
+        pipeline.add(item)
+
+        if time.Since(job.lastFlush) >= jobFlushInterval {
+            s.flushQueryJob(job, record)
+        }
+    }
+
+    pipeline.close()
+
+    // Query jobs are only cancelled by shutdown; the job stays RUNNING so
+    // the next process resumes it from its checkpoint
+    if ctx.Err() != nil {
+        job.setOperation("interrupted by service shutdown")
+        log.Printf("Query job %s interrupted by shutdown, checkpoint saved", job.ID)
+        return
+    }
+    if err := it.Err(); err != nil {
+        job.addError(fmt.Errorf("failed to read results: %w", err))
+        job.finish(JobStatusFailed)
+        return
+    }
+
+    job.finish(JobStatusCompleted)
+    log.Printf("Query job %s completed", job.ID)
+}
+
+// flushQueryJob persists the job's current progress
+func (s *Server) flushQueryJob(job *QueryJob, record *models.IngestionJob) {
+    job.snapshot(record)
+    job.lastFlush = time.Now()
+    if job.cursor != nil {
+        after, counts := job.cursor.checkpoint()
+        if data, err := json.Marshal(jobCheckpoint{After: after, Counts: &counts}); err == nil {
+            record.Checkpoint = data
+        }
+    }
+
+    err := s.db.Model(record).Select(
+        "status", "current_operation", "total_items", "processed_items", "success_count",
+        "error_count", "skipped_count", "recent_errors", "completed_at", "checkpoint",
+    ).Updates(record).Error
+    if err != nil {
+        log.Printf("Failed to persist progress for query job %s: %v", job.ID, err)
+    }
+}
@@ -0,0 +1,352 @@
+package blade_server
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "gorm.io/datatypes"
+)
+
+// ingestionJobColumns are the ingestion_jobs columns returned by sqlmock
+var ingestionJobColumns = []string{
+    "id", "job_id", "job_type", "status", "data_type", "sql_query",
+    "processed_items", "total_items", "success_count", "error_count",
+    "started_at", "completed_at", "resume_count",
+}
+
+func TestStartBLADEQueryJobPersistsPendingJob(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"item_id", "priority"}}
+    s, mock := newMockServer(t, &utils.Config{MockDatabricksURL: warehouse.start(t), BLADEDataTypes: []string{"maintenance"}})
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(`INSERT INTO "ingestion_jobs"`).
+        WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), models.JobTypeQuery, JobStatusPending,
+            "maintenance", "SELECT * FROM t WHERE id = :id", sqlmock.AnyArg(), "queued",
+            0, 0, 0, 0, 0, 0, nil, nil).
+        WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+    mock.ExpectCommit()
+
+    resp, err := s.StartBLADEQueryJob(context.Background(), &pb.BLADEQueryJobRequest{
+        DataType:   "maintenance",
+        SqlQuery:   "SELECT * FROM t WHERE id = :id",
+        Parameters: map[string]string{"id": "7"},
+    })
+    require.NoError(t, err)
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, JobStatusPending, resp.GetStatus())
+    assert.NotEmpty(t, resp.GetJobId())
+
+    // The query's columns were checked without reading its rows
+    assert.Equal(t, []string{"SELECT * FROM (SELECT * FROM t WHERE id = :id) AS job_rows LIMIT 0"}, warehouse.statements)
+
+    // Workers are woken to pick the job up
+    select {
+    case <-s.jobWakeup:
+    default:
+        t.Fatal("expected a worker wakeup")
+    }
+}
+
+func TestStartBLADEQueryJobValidatesRequest(t *testing.T) {
+    s, mock := newMockServer(t, &utils.Config{BLADEDataTypes: []string{"maintenance"}})
+
+    _, err := s.StartBLADEQueryJob(context.Background(), &pb.BLADEQueryJobRequest{DataType: "maintenance"})
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+    _, err = s.StartBLADEQueryJob(context.Background(), &pb.BLADEQueryJobRequest{DataType: "unknown", SqlQuery: "SELECT 1"})
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+    _, err = s.StartBLADEQueryJob(context.Background(), &pb.BLADEQueryJobRequest{
+        DataType:   "maintenance",
+        SqlQuery:   "SELECT 1",
+        Parameters: map[string]string{"bad name": "x"},
+    })
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+    _, err = s.StartBLADEQueryJob(context.Background(), &pb.BLADEQueryJobRequest{
+        DataType:   "maintenance",
+        SqlQuery:   "SELECT 1",
+        Parameters: map[string]string{"resume_item": "x"},
+    })
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+    // Nothing was persisted
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStartBLADEQueryJobRequiresItemIDColumn(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"id", "priority"}}
+    s, mock := newMockServer(t, &utils.Config{MockDatabricksURL: warehouse.start(t), BLADEDataTypes: []string{"maintenance"}})
+
+    _, err := s.StartBLADEQueryJob(context.Background(), &pb.BLADEQueryJobRequest{
+        DataType: "maintenance",
+        SqlQuery: "SELECT id, priority FROM t",
+    })
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+    assert.Contains(t, err.Error(), "item_id")
+
+    // Queries the warehouse rejects are invalid too
+    warehouse.state = StatementFailed
+    _, err = s.StartBLADEQueryJob(context.Background(), &pb.BLADEQueryJobRequest{
+        DataType: "maintenance",
+        SqlQuery: "SELECT item_id FROM missing_table",
+    })
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+    // Nothing was persisted
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimQueryJobSkipsLockedRows(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(`SELECT \* FROM "ingestion_jobs" WHERE \(job_type = \$1 AND status = \$2\) AND "ingestion_jobs"."deleted_at" IS NULL ORDER BY id,"ingestion_jobs"."id" LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+        WithArgs(models.JobTypeQuery, JobStatusPending, 1).
+        WillReturnRows(sqlmock.NewRows(ingestionJobColumns).
+            AddRow(3, "job-3", models.JobTypeQuery, JobStatusPending, "maintenance", "SELECT 1", 0, 0, 0, 0, nil, nil, 0))
+    mock.ExpectExec(`UPDATE "ingestion_jobs" SET "started_at"=\$1,"status"=\$2,"updated_at"=\$3 WHERE "ingestion_jobs"."deleted_at" IS NULL AND "id" = \$4`).
+        WithArgs(sqlmock.AnyArg(), JobStatusRunning, sqlmock.AnyArg(), 3).
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectCommit()
+
+    record, err := s.claimQueryJob(context.Background())
+    require.NoError(t, err)
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, "job-3", record.JobID)
+    assert.Equal(t, JobStatusRunning, record.Status)
+    assert.NotNil(t, record.StartedAt)
+}
+
+func TestClaimQueryJobKeepsResumedStartTime(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+    started := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(`FOR UPDATE SKIP LOCKED`).
+        WillReturnRows(sqlmock.NewRows(ingestionJobColumns).
+            AddRow(3, "job-3", models.JobTypeQuery, JobStatusPending, "maintenance", "SELECT 1", 40, 100, 40, 0, started, nil, 1))
+    mock.ExpectExec(`UPDATE "ingestion_jobs"`).
+        WithArgs(started, JobStatusRunning, sqlmock.AnyArg(), 3).
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectCommit()
+
+    record, err := s.claimQueryJob(context.Background())
+    require.NoError(t, err)
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, started, *record.StartedAt)
+    assert.Equal(t, 40, record.ProcessedItems)
+}
+
+func TestClaimQueryJobWithoutPendingJobs(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(`FOR UPDATE SKIP LOCKED`).WillReturnRows(sqlmock.NewRows(ingestionJobColumns))
+    mock.ExpectRollback()
+
+    record, err := s.claimQueryJob(context.Background())
+    assert.NoError(t, err)
+    assert.Nil(t, record)
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecoverQueryJobsAfterRestart(t *testing.T) {
+    s, mock := newMockServer(t, &utils.Config{JobMaxResumes: 3})
+
+    // Jobs with resumes left are requeued, the rest are failed
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE "ingestion_jobs" SET "current_operation"=\$1,"resume_count"=resume_count \+ 1,"status"=\$2,"updated_at"=\$3 WHERE \(job_type = \$4 AND status = \$5 AND resume_count < \$6\)`).
+        WithArgs("resuming", JobStatusPending, sqlmock.AnyArg(), models.JobTypeQuery, JobStatusRunning, 3).
+        WillReturnResult(sqlmock.NewResult(0, 2))
+    mock.ExpectCommit()
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE "ingestion_jobs" SET "completed_at"=\$1,"current_operation"=\$2,"recent_errors"=\$3,"status"=\$4,"updated_at"=\$5 WHERE \(job_type = \$6 AND status = \$7 AND job_id <> \$8\)`).
+        WithArgs(sqlmock.AnyArg(), "interrupted", sqlmock.AnyArg(), JobStatusFailed, sqlmock.AnyArg(), models.JobTypeQuery, JobStatusRunning, "").
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectCommit()
+
+    assert.NoError(t, s.recoverQueryJobs())
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBLADEQueryJobStatusReadsPersistedJob(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+    started := time.Now().Add(-time.Minute)
+
+    mock.ExpectQuery(`SELECT \* FROM "ingestion_jobs" WHERE \(job_id = \$1 AND job_type = \$2\)`).
+        WithArgs("job-3", models.JobTypeQuery, 1).
+        WillReturnRows(sqlmock.NewRows(ingestionJobColumns).
+            AddRow(3, "job-3", models.JobTypeQuery, JobStatusRunning, "maintenance", "SELECT 1", 25, 100, 20, 5, started, nil, 0))
+
+    resp, err := s.GetBLADEQueryJobStatus(context.Background(), &pb.JobRequest{JobId: "job-3"})
+    require.NoError(t, err)
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, JobStatusRunning, resp.GetStatus())
+    assert.Equal(t, float32(25), resp.GetProgress())
+    assert.Equal(t, int32(20), resp.GetSuccessCount())
+    assert.Equal(t, int32(5), resp.GetErrorCount())
+    assert.NotNil(t, resp.GetEstimatedCompletion())
+
+    mock.ExpectQuery(`SELECT \* FROM "ingestion_jobs"`).WillReturnRows(sqlmock.NewRows(ingestionJobColumns))
+    _, err = s.GetBLADEQueryJobStatus(context.Background(), &pb.JobRequest{JobId: "missing"})
+    assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestEstimateCompletion(t *testing.T) {
+    // A quarter of the items took a minute, so three minutes remain
+    start := time.Now().Add(-time.Minute)
+    eta := estimateCompletion(start, nil, 25, 100)
+    require.NotNil(t, eta)
+    assert.WithinDuration(t, time.Now().Add(3*time.Minute), eta.AsTime(), 5*time.Second)
+
+    // Finished jobs report when they ended
+    end := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+    assert.Equal(t, end, estimateCompletion(start, &end, 100, 100).AsTime())
+
+    // Nothing to extrapolate from yet
+    assert.Nil(t, estimateCompletion(start, nil, 0, 100))
+    assert.Nil(t, estimateCompletion(start, nil, 10, 0))
+    assert.Nil(t, estimateCompletion(time.Time{}, nil, 10, 100))
+}
+
+func TestQueryJobReadsRowsInKeyOrder(t *testing.T) {
+    job := &QueryJob{
+        SQLQuery:           "SELECT * FROM fleet.maintenance WHERE base = :base; ",
+        queryJobParameters: queryJobParameters{QueryParameters: map[string]string{"base": "Ramstein"}},
+    }
+
+    query, params := job.keyedQuery(nil)
+    assert.Equal(t, "SELECT * FROM (SELECT * FROM fleet.maintenance WHERE base = :base) AS job_rows ORDER BY item_id ASC", query)
+    assert.Len(t, params, 1)
+
+    query, params = job.keyedQuery(&rowPosition{ItemID: "Q-2"})
+    assert.Equal(t, "SELECT * FROM (SELECT * FROM fleet.maintenance WHERE base = :base) AS job_rows WHERE item_id > :resume_item ORDER BY item_id ASC", query)
+    require.Len(t, params, 2)
+    assert.Equal(t, "resume_item", params[1].Name)
+    assert.Equal(t, "Q-2", *params[1].Value)
+}
+
+func TestResumedQueryJobCountsEachRowOnce(t *testing.T) {
+    // Q-3's upload hangs until the first run is interrupted
+    var interrupted atomic.Bool
+    hung := make(chan struct{})
+    var once sync.Once
+    catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if strings.HasSuffix(r.URL.Path, "/batch") {
+            http.NotFound(w, r)
+            return
+        }
+        body, _ := io.ReadAll(r.Body)
+        if bytes.Contains(body, []byte("Q-3")) && !interrupted.Load() {
+            once.Do(func() { close(hung) })
+            <-r.Context().Done()
+            return
+        }
+        w.Write([]byte(`{"catalog_id":"cat-1"}`))
+    }))
+    t.Cleanup(catalog.Close)
+
+    warehouse := &fakeWarehouse{columns: []string{"item_id"}, rows: [][]interface{}{{"Q-1"}, {"Q-2"}, {"Q-3"}, {"Q-4"}}}
+    s, _ := newRecordingServer(t, &utils.Config{
+        MockDatabricksURL: warehouse.start(t),
+        CatalogURL:        catalog.URL,
+        CatalogBatchSize:  1,
+        ConcurrentUploads: 2,
+        BLADEDataTypes:    []string{"maintenance"},
+    })
+    record := &models.IngestionJob{
+        JobID:    "job-1",
+        JobType:  models.JobTypeQuery,
+        Status:   JobStatusRunning,
+        DataType: "maintenance",
+        SQLQuery: "SELECT * FROM fleet.maintenance",
+    }
+    record.ID = 1
+
+    // The first run is interrupted after Q-4 was delivered but not Q-3
+    ctx, cancel := context.WithCancel(context.Background())
+    job := &QueryJob{jobProgress: newJobProgress(), ID: record.JobID, DataType: record.DataType, SQLQuery: record.SQLQuery, cancel: cancel}
+    finished := make(chan struct{})
+    go func() {
+        defer close(finished)
+        s.executeQueryJob(ctx, job, record, jobCheckpoint{})
+    }()
+    <-hung
+    require.Eventually(t, func() bool {
+        job.mu.RLock()
+        defer job.mu.RUnlock()
+        return job.processedItems == 3
+    }, 5*time.Second, 10*time.Millisecond)
+    interrupted.Store(true)
+    cancel()
+    <-finished
+    s.flushQueryJob(job, record)
+
+    // The interrupted item is not counted as failed, and the checkpoint
+    // only covers the rows before it
+    assert.Equal(t, 3, record.ProcessedItems)
+    assert.Zero(t, record.ErrorCount)
+    cp, err := loadCheckpoint(record)
+    require.NoError(t, err)
+    require.NotNil(t, cp.After)
+    assert.Equal(t, "Q-2", cp.After.ItemID)
+    assert.Equal(t, itemCounts{Processed: 2, Succeeded: 2}, cp.queryCounts())
+
+    // The resumed run reads on after the checkpoint and counts Q-4 once
+    warehouse.rows = [][]interface{}{{"Q-3"}, {"Q-4"}}
+    s.runQueryJob(record)
+
+    assert.Equal(t, "SELECT * FROM (SELECT * FROM fleet.maintenance) AS job_rows WHERE item_id > :resume_item ORDER BY item_id ASC", warehouse.statements[1])
+    assert.Equal(t, JobStatusCompleted, record.Status)
+    assert.Equal(t, 4, record.TotalItems)
+    assert.Equal(t, 4, record.ProcessedItems)
+    assert.Equal(t, 4, record.SuccessCount)
+    assert.Zero(t, record.ErrorCount)
+}
+
+func TestQueryJobWithCorruptCheckpointFails(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"item_id"}, rows: [][]interface{}{{"Q-1"}}}
+    s, rec := newRecordingServer(t, &utils.Config{
+        MockDatabricksURL: warehouse.start(t),
+        BLADEDataTypes:    []string{"maintenance"},
+    })
+    record := &models.IngestionJob{
+        JobID:          "job-1",
+        JobType:        models.JobTypeQuery,
+        Status:         JobStatusRunning,
+        DataType:       "maintenance",
+        SQLQuery:       "SELECT * FROM fleet.maintenance",
+        ProcessedItems: 40,
+        Checkpoint:     datatypes.JSON(`{"after":`),
+    }
+    record.ID = 1
+
+    s.runQueryJob(record)
+
+    // The job fails without starting over, leaving its counters and
+    // checkpoint as they were
+    assert.Empty(t, warehouse.statements)
+    updates := rec.matching(`UPDATE "ingestion_jobs" SET`, `AND "id" = `)
+    require.Len(t, updates, 1)
+    assert.Contains(t, updates[0].Args, JobStatusFailed)
+    assert.NotContains(t, updates[0].SQL, "checkpoint")
+    assert.NotContains(t, updates[0].SQL, "processed_items")
+}
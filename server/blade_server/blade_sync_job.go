@@ -0,0 +1,930 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "sort"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "blade-ingestion-service/database/datasource"
+    "blade-ingestion-service/database/models"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/google/uuid"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/emptypb"
+    "google.golang.org/protobuf/types/known/timestamppb"
+    "gorm.io/gorm"
+)
+
+// SyncJob is a Databricks to catalog synchronization job
+type SyncJob struct {
+    jobProgress
+
+    ID             string
+    SyncType       pb.SyncJobRequest_SyncType
+    DataTypes      []string
+    DataSourceID   uint
+    Filter         string
+    MaxItems       int
+    progressByType map[string]int32
+    operations     map[string]string
+    changes        syncChanges
+    cancel         context.CancelFunc
+
+    // Each data type's checkpoint, and the cursors of those being read
+    types   map[string]*typeCheckpoint
+    cursors map[string]*progressCursor
+
+    // The data source each data type is read from, once resolved
+    sources map[string]uint
+
+    // Persisted job state; flushMu serializes writes of it
+    record  *models.IngestionJob
+    flushMu sync.Mutex
+
+    // Closed when the job has finished
+    done chan struct{}
+}
+
+// syncJobParameters is the persisted request of a sync job
+type syncJobParameters struct {
+    SyncType     string   `json:"sync_type"`
+    DataTypes    []string `json:"data_types"`
+    DataSourceID uint     `json:"data_source_id,omitempty"`
+    Filter       string   `json:"filter,omitempty"`
+    MaxItems     int      `json:"max_items,omitempty"`
+}
+
+func newSyncJob(id string, params syncJobParameters) *SyncJob {
+    job := &SyncJob{
+        jobProgress:    newJobProgress(),
+        ID:             id,
+        SyncType:       pb.SyncJobRequest_SyncType(pb.SyncJobRequest_SyncType_value[params.SyncType]),
+        DataTypes:      params.DataTypes,
+        DataSourceID:   params.DataSourceID,
+        Filter:         params.Filter,
+        MaxItems:       params.MaxItems,
+        progressByType: make(map[string]int32, len(params.DataTypes)),
+        operations:     make(map[string]string, len(params.DataTypes)),
+        types:          make(map[string]*typeCheckpoint, len(params.DataTypes)),
+        cursors:        make(map[string]*progressCursor, len(params.DataTypes)),
+        sources:        make(map[string]uint, len(params.DataTypes)),
+        done:           make(chan struct{}),
+    }
+    for _, dataType := range params.DataTypes {
+        job.progressByType[dataType] = 0
+        job.types[dataType] = &typeCheckpoint{}
+    }
+    return job
+}
+
+// resumeFrom restores the checkpoint of an interrupted job, and the
+// counters of the rows it covers
+func (j *SyncJob) resumeFrom(record *models.IngestionJob) error {
+    cp, err := loadCheckpoint(record)
+    if err != nil {
+        return err
+    }
+    var counts itemCounts
+    for _, dataType := range j.DataTypes {
+        counts.add(cp.Types[dataType].Counts)
+    }
+    if err := j.restore(record, counts); err != nil {
+        return err
+    }
+
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.totalItems = 0
+    for _, dataType := range j.DataTypes {
+        tc, ok := cp.Types[dataType]
+        if !ok {
+            continue
+        }
+        j.types[dataType] = &tc
+        j.progressByType[dataType] = tc.Counts.Processed
+        j.changes.Added += tc.Counts.Added
+        j.changes.Updated += tc.Counts.Updated
+        j.changes.Unchanged += tc.Counts.Skipped
+        j.changes.Deleted += tc.Deleted
+
+        // Types still to be read add their remaining rows once queried
+        if tc.Done {
+            j.totalItems += tc.Total
+        } else {
+            j.totalItems += tc.Counts.Processed
+        }
+    }
+    return nil
+}
+
+// typeState returns a copy of a data type's checkpoint
+func (j *SyncJob) typeState(dataType string) typeCheckpoint {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+    return *j.types[dataType]
+}
+
+// startReading registers a data type's cursor and the rows its query
+// returned
+func (j *SyncJob) startReading(dataType string, cursor *progressCursor, rows int) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.cursors[dataType] = cursor
+    j.types[dataType].Total = j.types[dataType].Counts.Processed + int32(rows)
+    j.totalItems += int32(rows)
+}
+
+// markUnreadRows notes that a data type had rows that could not be read
+func (j *SyncJob) markUnreadRows(dataType string) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.types[dataType].UnreadRows = true
+}
+
+// finishType marks a data type as synced, or failed, so a resumed job
+// skips it
+func (j *SyncJob) finishType(dataType string, failed bool) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.types[dataType].Done = true
+    j.types[dataType].Failed = failed
+}
+
+// setTypeSource records the data source a data type is read from
+func (j *SyncJob) setTypeSource(dataType string, sourceID uint) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.sources[dataType] = sourceID
+}
+
+// typeSource returns the data source a data type is read from, or 0 if it
+// has none or it was not resolved
+func (j *SyncJob) typeSource(dataType string) uint {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+    return j.sources[dataType]
+}
+
+// checkpoint captures the job's position for persisting
+func (j *SyncJob) checkpoint() jobCheckpoint {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+
+    cp := jobCheckpoint{Types: make(map[string]typeCheckpoint, len(j.types))}
+    for dataType, tc := range j.types {
+        state := *tc
+        if cursor := j.cursors[dataType]; cursor != nil {
+            state.After, state.Counts = cursor.checkpoint()
+            if state.Done {
+                state.Counts = cursor.tally()
+            }
+        }
+        cp.Types[dataType] = state
+    }
+    return cp
+}
+
+// recordTypeItem records progress for an item of the given data type
+func (j *SyncJob) recordTypeItem(dataType, itemID string, err error) {
+    j.recordItem(dataType+"/"+itemID, err)
+    if isInterruption(err) {
+        return
+    }
+    j.mu.Lock()
+    j.progressByType[dataType]++
+    j.mu.Unlock()
+}
+
+// syncChanges counts what a sync did to the locally known items
+type syncChanges struct {
+    Added     int32 `json:"added"`
+    Updated   int32 `json:"updated"`
+    Unchanged int32 `json:"unchanged"`
+    Deleted   int32 `json:"deleted"`
+}
+
+func (c syncChanges) String() string {
+    return fmt.Sprintf("%d added, %d updated, %d unchanged, %d deleted", c.Added, c.Updated, c.Unchanged, c.Deleted)
+}
+
+// setTypeOperation updates the operation of one data type. The job's
+// current operation lists every data type's, since they run concurrently.
+func (j *SyncJob) setTypeOperation(dataType, format string, args ...interface{}) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.operations[dataType] = fmt.Sprintf(format, args...)
+
+    parts := make([]string, 0, len(j.DataTypes))
+    for _, dt := range j.DataTypes {
+        if op, ok := j.operations[dt]; ok {
+            parts = append(parts, dt+": "+op)
+        }
+    }
+    j.currentOperation = strings.Join(parts, "; ")
+}
+
+// syncedItemOutcome counts a synced item, as added or updated depending on
+// whether it was known before the sync if it was delivered
+func syncedItemOutcome(known bool, err error) itemCounts {
+    outcome := itemOutcome(err)
+    if outcome.Succeeded > 0 {
+        if known {
+            outcome.Updated = 1
+        } else {
+            outcome.Added = 1
+        }
+    }
+    return outcome
+}
+
+// recordSyncedItem records progress for a synced item and the change it made
+func (j *SyncJob) recordSyncedItem(dataType, itemID string, outcome itemCounts, err error) {
+    j.recordTypeItem(dataType, itemID, err)
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.changes.Added += outcome.Added
+    j.changes.Updated += outcome.Updated
+    j.changes.Unchanged += outcome.Skipped
+}
+
+// recordDeleted counts items of a data type tombstoned because they left
+// the source
+func (j *SyncJob) recordDeleted(dataType string, n int) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.types[dataType].Deleted += int32(n)
+    j.changes.Deleted += int32(n)
+}
+
+// StartBLADESync starts an asynchronous sync job
+func (s *Server) StartBLADESync(ctx context.Context, req *pb.SyncJobRequest) (*pb.JobResponse, error) {
+    job, err := s.startSync(req)
+    if err != nil {
+        return nil, err
+    }
+
+    return &pb.JobResponse{
+        JobId:     job.ID,
+        Status:    JobStatusRunning,
+        Message:   fmt.Sprintf("%s sync started for %v", job.SyncType, job.DataTypes),
+        StartTime: timestamppb.New(job.startTime),
+    }, nil
+}
+
+// startSync validates a sync request and runs it in the background unless
+// another sync is running
+func (s *Server) startSync(req *pb.SyncJobRequest) (*SyncJob, error) {
+    dataTypes, err := s.syncDataTypes(req)
+    if err != nil {
+        return nil, err
+    }
+    for _, dataType := range dataTypes {
+        if _, _, err := CompileFilter(dataType, req.GetFilter()); err != nil {
+            return nil, status.Error(codes.InvalidArgument, err.Error())
+        }
+    }
+
+    s.syncMu.Lock()
+    defer s.syncMu.Unlock()
+
+    if s.currentSync != nil && s.currentSync.isRunning() {
+        return nil, status.Errorf(codes.FailedPrecondition, "sync job %s is already running", s.currentSync.ID)
+    }
+
+    jobParams := syncJobParameters{
+        SyncType:     req.GetSyncType().String(),
+        DataTypes:    dataTypes,
+        DataSourceID: uint(req.GetDataSourceId()),
+        Filter:       req.GetFilter(),
+        MaxItems:     int(req.GetMaxItems()),
+    }
+    params, err := json.Marshal(jobParams)
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to encode job: %v", err)
+    }
+
+    job := newSyncJob(uuid.New().String(), jobParams)
+    job.record = &models.IngestionJob{
+        JobID:            job.ID,
+        JobType:          models.JobTypeSync,
+        Status:           JobStatusRunning,
+        DataType:         strings.Join(dataTypes, ","),
+        Parameters:       params,
+        CurrentOperation: "starting",
+        StartedAt:        &job.startTime,
+    }
+    if err := s.db.Create(job.record).Error; err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to create job: %v", err)
+    }
+
+    s.launchSync(job)
+    return job, nil
+}
+
+// runningSync returns the sync job in progress, if any
+func (s *Server) runningSync() *SyncJob {
+    s.syncMu.Lock()
+    defer s.syncMu.Unlock()
+    if s.currentSync != nil && s.currentSync.isRunning() {
+        return s.currentSync
+    }
+    return nil
+}
+
+// launchSync runs a sync job in the background. The caller holds syncMu.
+func (s *Server) launchSync(job *SyncJob) {
+    jobCtx, cancel := context.WithCancel(context.Background())
+    job.cancel = cancel
+    s.currentSync = job
+
+    s.jobsWG.Add(1)
+    go func() {
+        defer s.jobsWG.Done()
+        s.runSyncJob(jobCtx, job)
+    }()
+}
+
+// recoverSyncJobs resumes the latest sync job left RUNNING by a previous
+// process from its checkpoint, unless it was already resumed JobMaxResumes
+// times. Other interrupted sync jobs are marked failed.
+func (s *Server) recoverSyncJobs() error {
+    var record models.IngestionJob
+    err := s.db.Where("job_type = ? AND status = ?", models.JobTypeSync, JobStatusRunning).
+        Order("id DESC").
+        Limit(1).Find(&record).Error
+    if err != nil {
+        return fmt.Errorf("failed to recover sync jobs: %w", err)
+    }
+
+    // A job whose parameters or checkpoint cannot be decoded fails rather
+    // than starting over
+    var job *SyncJob
+    if record.ID != 0 && record.ResumeCount < s.config.JobMaxResumes {
+        var params syncJobParameters
+        err := json.Unmarshal(record.Parameters, &params)
+        if err != nil {
+            err = fmt.Errorf("invalid parameters: %w", err)
+        } else {
+            job = newSyncJob(record.JobID, params)
+            job.record = &record
+            err = job.resumeFrom(&record)
+        }
+        if err != nil {
+            s.failUnresumableJob(&record, err)
+            job = nil
+        }
+    }
+    if job == nil {
+        return s.failInterruptedJobs(models.JobTypeSync, "")
+    }
+    if err := s.failInterruptedJobs(models.JobTypeSync, record.JobID); err != nil {
+        return err
+    }
+
+    record.ResumeCount++
+    if err := s.db.Model(&record).Update("resume_count", record.ResumeCount).Error; err != nil {
+        return fmt.Errorf("failed to recover sync jobs: %w", err)
+    }
+
+    s.syncMu.Lock()
+    s.launchSync(job)
+    s.syncMu.Unlock()
+
+    log.Printf("Resuming sync job %s from its checkpoint (resume %d of %d)",
+        job.ID, record.ResumeCount, s.config.JobMaxResumes)
+    return nil
+}
+
+// flushSyncJob persists the job's progress and checkpoint
+func (s *Server) flushSyncJob(job *SyncJob) {
+    job.flushMu.Lock()
+    defer job.flushMu.Unlock()
+
+    job.snapshot(job.record)
+    if data, err := json.Marshal(job.checkpoint()); err == nil {
+        job.record.Checkpoint = data
+    }
+
+    err := s.db.Model(job.record).Select(
+        "status", "current_operation", "total_items", "processed_items", "success_count",
+        "error_count", "skipped_count", "recent_errors", "completed_at", "checkpoint",
+    ).Updates(job.record).Error
+    if err != nil {
+        log.Printf("Failed to persist progress for sync job %s: %v", job.ID, err)
+    }
+}
+
+// StopBLADESync cancels the running sync job
+func (s *Server) StopBLADESync(ctx context.Context, _ *emptypb.Empty) (*pb.JobResponse, error) {
+    s.syncMu.Lock()
+    job := s.currentSync
+    s.syncMu.Unlock()
+
+    if job == nil || !job.isRunning() {
+        return nil, status.Error(codes.FailedPrecondition, "no sync job is running")
+    }
+
+    job.cancel()
+
+    return &pb.JobResponse{
+        JobId:     job.ID,
+        Status:    JobStatusCancelled,
+        Message:   "Sync job cancellation requested",
+        StartTime: timestamppb.New(job.startTime),
+    }, nil
+}
+
+// GetSyncStatus returns the status of the current or last sync job
+func (s *Server) GetSyncStatus(ctx context.Context, _ *emptypb.Empty) (*pb.SyncStatusResponse, error) {
+    s.syncMu.Lock()
+    job := s.currentSync
+    s.syncMu.Unlock()
+
+    if job == nil {
+        return &pb.SyncStatusResponse{Status: "IDLE"}, nil
+    }
+
+    job.mu.RLock()
+    defer job.mu.RUnlock()
+
+    progressByType := make(map[string]int32, len(job.progressByType))
+    for k, v := range job.progressByType {
+        progressByType[k] = v
+    }
+
+    return &pb.SyncStatusResponse{
+        JobId:               job.ID,
+        Status:              job.status,
+        CurrentOperation:    job.currentOperation,
+        TotalItems:          job.totalItems,
+        ProcessedItems:      job.processedItems,
+        SuccessCount:        job.successCount,
+        ErrorCount:          job.errorCount,
+        StartTime:           timestamppb.New(job.startTime),
+        EstimatedCompletion: job.estimatedCompletionLocked(),
+        RecentErrors:        append([]string(nil), job.recentErrors...),
+        ProgressByType:      progressByType,
+        ItemsAdded:          job.changes.Added,
+        ItemsUpdated:        job.changes.Updated,
+        ItemsUnchanged:      job.changes.Unchanged,
+        ItemsDeleted:        job.changes.Deleted,
+    }, nil
+}
+
+// syncDataTypes resolves which data types a sync request covers. A sync of
+// a data source covers its data type.
+func (s *Server) syncDataTypes(req *pb.SyncJobRequest) ([]string, error) {
+    if req.GetDataSourceId() != 0 {
+        source, err := s.sourceByID(context.Background(), uint(req.GetDataSourceId()))
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, status.Errorf(codes.NotFound, "data source %d not found", req.GetDataSourceId())
+        }
+        if err != nil {
+            return nil, status.Errorf(codes.Internal, "%v", err)
+        }
+        if !source.Enabled {
+            return nil, status.Errorf(codes.FailedPrecondition, "data source %s is disabled", source.TypeName)
+        }
+        if req.GetDataType() != "" && req.GetDataType() != source.DataType {
+            return nil, status.Errorf(codes.InvalidArgument, "data source %s holds %s data, not %s", source.TypeName, source.DataType, req.GetDataType())
+        }
+        if !s.config.IsBLADEDataType(source.DataType) {
+            return nil, status.Errorf(codes.FailedPrecondition, "data source %s has invalid data type: %s", source.TypeName, source.DataType)
+        }
+        return []string{source.DataType}, nil
+    }
+
+    if req.GetDataType() != "" {
+        if !s.config.IsBLADEDataType(req.GetDataType()) {
+            return nil, status.Errorf(codes.InvalidArgument, "invalid data type: %s", req.GetDataType())
+        }
+        return []string{req.GetDataType()}, nil
+    }
+
+    if req.GetSyncType() == pb.SyncJobRequest_DATA_TYPE {
+        return nil, status.Error(codes.InvalidArgument, "dataType is required for DATA_TYPE sync")
+    }
+
+    return append([]string(nil), s.config.BLADEDataTypes...), nil
+}
+
+// runSyncJob syncs the job's data types concurrently, at most
+// SyncConcurrency at a time. A data type that fails is recorded on its data
+// sources and in the job's errors without stopping the others; types
+// stopped by a cancel are recorded as cancelled. Progress is
+// checkpointed every jobFlushInterval; a job interrupted by shutdown stays
+// RUNNING so the next process resumes it.
+func (s *Server) runSyncJob(ctx context.Context, job *SyncJob) {
+    defer close(job.done)
+    defer job.cancel()
+
+    log.Printf("Sync job %s started (%s, types=%v)", job.ID, job.SyncType, job.DataTypes)
+
+    stopFlush := make(chan struct{})
+    flushed := make(chan struct{})
+    go func() {
+        defer close(flushed)
+        ticker := time.NewTicker(jobFlushInterval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-stopFlush:
+                return
+            case <-ticker.C:
+                s.flushSyncJob(job)
+            }
+        }
+    }()
+
+    concurrency := s.config.SyncConcurrency
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    slots := make(chan struct{}, concurrency)
+
+    var wg sync.WaitGroup
+    var typeFailures atomic.Int32
+    for _, dataType := range job.DataTypes {
+        // Types finished before an interruption are not synced again
+        if state := job.typeState(dataType); state.Done {
+            if state.Failed {
+                typeFailures.Add(1)
+            }
+            continue
+        }
+
+        select {
+        case slots <- struct{}{}:
+        case <-ctx.Done():
+        }
+        if ctx.Err() != nil {
+            break
+        }
+
+        wg.Add(1)
+        go func(dataType string) {
+            defer wg.Done()
+            defer func() { <-slots }()
+
+            err := s.syncDataType(ctx, job, dataType)
+            switch {
+            case err != nil && ctx.Err() != nil && s.shuttingDown.Load():
+                // Resumed by the next process
+            case err != nil && ctx.Err() != nil && isInterruption(err):
+                // Stopped by StopBLADESync
+                job.setTypeOperation(dataType, "cancelled")
+                job.finishType(dataType, false)
+                s.recordSourceSync(job.typeSource(dataType), JobStatusCancelled, nil)
+            case err != nil:
+                typeFailures.Add(1)
+                job.addError(fmt.Errorf("%s: %w", dataType, err))
+                job.setTypeOperation(dataType, "failed")
+                job.finishType(dataType, true)
+                s.recordSourceSync(job.typeSource(dataType), JobStatusFailed, err)
+            default:
+                job.setTypeOperation(dataType, "done")
+                job.finishType(dataType, false)
+                s.recordSourceSync(job.typeSource(dataType), JobStatusCompleted, nil)
+            }
+        }(dataType)
+    }
+    wg.Wait()
+    close(stopFlush)
+    <-flushed
+
+    if ctx.Err() != nil && s.shuttingDown.Load() {
+        job.setOperation("interrupted by service shutdown")
+        s.flushSyncJob(job)
+        log.Printf("Sync job %s interrupted by shutdown, checkpoint saved", job.ID)
+        return
+    }
+
+    finalStatus := JobStatusCompleted
+    if succeeded, failed := job.counts(); ctx.Err() != nil {
+        finalStatus = JobStatusCancelled
+    } else if (failed > 0 && succeeded == 0) || int(typeFailures.Load()) == len(job.DataTypes) {
+        finalStatus = JobStatusFailed
+    }
+    job.finish(finalStatus)
+
+    // Report the changes alongside the final status
+    job.mu.Lock()
+    job.currentOperation += ": " + job.changes.String()
+    changes := job.changes
+    job.mu.Unlock()
+    s.flushSyncJob(job)
+
+    log.Printf("Sync job %s finished: %s (%s)", job.ID, finalStatus, changes)
+}
+
+// syncDataType fetches and ingests the rows for one data type: all of them,
+// or for INCREMENTAL syncs those past the data source's watermark. When a
+// sync reads every row, local items missing from the source are tombstoned.
+func (s *Server) syncDataType(ctx context.Context, job *SyncJob, dataType string) error {
+    job.setTypeOperation(dataType, "querying data")
+
+    // A resumed sync reads on from the type's checkpoint
+    state := job.typeState(dataType)
+    limit := job.MaxItems
+    if limit > 0 {
+        if limit -= int(state.Counts.Processed); limit <= 0 {
+            return nil
+        }
+    }
+
+    var query string
+    var params []StatementParameter
+    var err error
+    var source *datasource.DataSource
+    var watermark *watermarkTracker
+
+    // Rows are read from the job's data source, or the data type's, whose
+    // items are the ones a complete read can find deleted
+    if job.DataSourceID != 0 {
+        source, err = s.sourceByID(ctx, job.DataSourceID)
+    } else {
+        source, err = s.defaultSource(ctx, dataType)
+    }
+    if err != nil {
+        return err
+    }
+    var sourceID uint
+    if source != nil {
+        sourceID = source.ID
+        job.setTypeSource(dataType, sourceID)
+    }
+
+    incremental := job.SyncType == pb.SyncJobRequest_INCREMENTAL
+    if incremental {
+        if source == nil {
+            return fmt.Errorf("incremental sync requires an enabled data source")
+        }
+        watermark = &watermarkTracker{column: sourceWatermarkColumn(source)}
+        if state.After != nil && state.After.Mark != nil {
+            watermark.max = *state.After.Mark
+        }
+        query, params, err = s.buildIncrementalQuery(source, job.Filter, limit, state.After)
+    } else {
+        // Without MaxItems every row is read, so deletions can be detected
+        if limit == 0 {
+            limit = utils.Unlimited
+        }
+        query, params, err = s.buildSyncQuery(dataType, s.tableFor(source, dataType), job.Filter, limit, state.After)
+    }
+    if err != nil {
+        return err
+    }
+
+    if source != nil {
+        if err := s.adoptLegacyItems(ctx, source); err != nil {
+            return err
+        }
+    }
+    known, err := s.knownItemIDs(ctx, dataType)
+    if err != nil {
+        return err
+    }
+
+    cursor := newProgressCursor(state.After, state.Counts)
+    pipeline := s.newIngestPipeline(ctx, func(itemID string, err error) {
+        // Interrupted items are read again on resume
+        if isInterruption(err) {
+            return
+        }
+        _, ok := known[itemID]
+        outcome := syncedItemOutcome(ok, err)
+        job.recordSyncedItem(dataType, itemID, outcome, err)
+        cursor.complete(itemID, outcome)
+    })
+    defer pipeline.close()
+
+    it, err := s.databricks.StreamQuery(pipeline.ctx, query, params...)
+    if err != nil {
+        return fmt.Errorf("query failed: %w", err)
+    }
+    defer it.Close()
+    job.startReading(dataType, cursor, int(it.TotalRows()))
+
+    // Rows seen in the source, used to find items that were deleted there.
+    // Rows before a checkpoint were read by the interrupted run.
+    seen := make(map[string]struct{})
+    if state.After != nil {
+        for itemID := range known {
+            if itemID <= state.After.ItemID {
+                seen[itemID] = struct{}{}
+            }
+        }
+    }
+
+    job.setTypeOperation(dataType, "uploading items")
+    for it.Next() {
+        if pipeline.ctx.Err() != nil {
+            break
+        }
+
+        row, err := it.Row()
+        if err != nil {
+            job.markUnreadRows(dataType)
+            job.recordTypeItem(dataType, "", err)
+            cursor.skip(nil, itemOutcome(err))
+            continue
+        }
+        item, err := TransformToBLADEItem(dataType, row)
+        if err != nil {
+            job.markUnreadRows(dataType)
+            job.recordTypeItem(dataType, "", err)
+            cursor.skip(nil, itemOutcome(err))
+            continue
+        }
+        item.IngestionJobID = job.ID
+        item.DataSourceID = sourceID
+        seen[item.ItemID] = struct{}{}
+
+        pos := &rowPosition{ItemID: item.ItemID}
+        if watermark != nil {
+            pos.Mark = watermark.observe(row)
+        }
+        cursor.add(item.ItemID, pos)
+        pipeline.add(item)
+    }
+
+    pipeline.close()
+    if ctx.Err() != nil {
+        return ctx.Err()
+    }
+    if err := it.Err(); err != nil {
+        return err
+    }
+
+    // Failed rows, including those before a checkpoint, hold the watermark
+    state = job.typeState(dataType)
+    if incremental {
+        if failed := cursor.tally().Failed; state.UnreadRows || failed > 0 {
+            log.Printf("Sync job %s left the %s watermark at %v: %d items not delivered",
+                job.ID, dataType, source.Watermark, failed)
+            return nil
+        }
+        return s.advanceWatermark(ctx, source, watermark.max)
+    }
+
+    // Deletions can only be inferred when every source row was read
+    if job.Filter == "" && job.MaxItems == 0 && !it.Truncated() && !state.UnreadRows {
+        return s.tombstoneMissing(ctx, job, dataType, sourceID, known, seen)
+    }
+    return nil
+}
+
+// buildSyncQuery selects a data type's rows from its table in item ID
+// order, after the given position when resuming
+func (s *Server) buildSyncQuery(dataType, table, filter string, limit int, after *rowPosition) (string, []StatementParameter, error) {
+    where, params, err := CompileFilter(dataType, filter)
+    if err != nil {
+        return "", nil, err
+    }
+    if after != nil {
+        where = andWhere(where, "item_id > :resume_item")
+        params = append(params, NewParameter("resume_item", after.ItemID))
+    }
+    return s.config.GetTableQuery(table, where, "item_id ASC", limit, 0), params, nil
+}
+
+// andWhere adds a condition to a possibly empty WHERE clause
+func andWhere(where, cond string) string {
+    if where == "" {
+        return cond
+    }
+    return "(" + where + ") AND " + cond
+}
+
+// knownItemIDs returns the IDs of the live local items of a data type,
+// mapped to the data source each was last ingested from
+func (s *Server) knownItemIDs(ctx context.Context, dataType string) (map[string]uint, error) {
+    var items []models.BLADEItem
+    err := s.db.WithContext(ctx).Select("item_id", "data_source_id").
+        Where("data_type = ?", dataType).
+        Find(&items).Error
+    if err != nil {
+        return nil, fmt.Errorf("failed to list known items: %w", err)
+    }
+
+    known := make(map[string]uint, len(items))
+    for _, item := range items {
+        known[item.ItemID] = item.DataSourceID
+    }
+    return known, nil
+}
+
+// adoptLegacyItems assigns a data type's items ingested before it had a
+// data source to source, if it is the type's only enabled source, so that
+// its full syncs can find them deleted. With several sources it is unknown
+// which one such an item came from, and it is left alone.
+func (s *Server) adoptLegacyItems(ctx context.Context, source *datasource.DataSource) error {
+    owner, err := s.defaultSource(ctx, source.DataType)
+    if status.Code(err) == codes.FailedPrecondition || err == nil && (owner == nil || owner.ID != source.ID) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    result := s.db.WithContext(ctx).Model(&models.BLADEItem{}).
+        Where("data_type = ? AND (data_source_id IS NULL OR data_source_id = 0)", source.DataType).
+        Update("data_source_id", source.ID)
+    if result.Error != nil {
+        return fmt.Errorf("failed to assign items to data source %s: %w", source.TypeName, result.Error)
+    }
+    if result.RowsAffected > 0 {
+        log.Printf("Assigned %d %s items without a data source to %s", result.RowsAffected, source.DataType, source.TypeName)
+    }
+    return nil
+}
+
+// tombstoneMissing retracts known items of the synced data source that are
+// no longer present in it from their sinks and soft-deletes them. Items
+// ingested from other sources, or without one, are left alone, and a sync
+// without a data source tombstones nothing. Items that could not be
+// retracted are kept so the next sync tries again.
+func (s *Server) tombstoneMissing(ctx context.Context, job *SyncJob, dataType string, sourceID uint, known map[string]uint, seen map[string]struct{}) error {
+    if sourceID == 0 {
+        return nil
+    }
+
+    var missing []string
+    for itemID, itemSource := range known {
+        if _, ok := seen[itemID]; !ok && itemSource == sourceID {
+            missing = append(missing, itemID)
+        }
+    }
+    if len(missing) == 0 {
+        return nil
+    }
+    sort.Strings(missing)
+
+    job.setTypeOperation(dataType, "tombstoning %d deleted items", len(missing))
+    var deleted []string
+    for _, itemID := range missing {
+        if ctx.Err() != nil {
+            break
+        }
+        if _, err := s.retractItem(ctx, itemID); err != nil {
+            job.addError(err)
+            continue
+        }
+        err := s.db.WithContext(ctx).Where("item_id = ?", itemID).Delete(&models.BLADEItem{}).Error
+        if err != nil {
+            job.addError(fmt.Errorf("failed to tombstone %s: %w", itemID, err))
+            continue
+        }
+        deleted = append(deleted, itemID)
+    }
+
+    // Tombstoned items have nothing left to replay
+    s.clearDeadLetters(ctx, deleted)
+    job.recordDeleted(dataType, len(deleted))
+
+    log.Printf("Sync job %s tombstoned %d deleted %s items", job.ID, len(deleted), dataType)
+    return ctx.Err()
+}
+
+// recordSourceSync stores the outcome of a sync on the data source it read.
+// Syncs without a data source have nothing to record.
+func (s *Server) recordSourceSync(sourceID uint, syncStatus string, syncErr error) {
+    if sourceID == 0 {
+        return
+    }
+
+    now := time.Now()
+    updates := map[string]interface{}{
+        "last_sync_time":     &now,
+        "last_sync_status":   syncStatus,
+        "last_error_message": "",
+    }
+    if syncErr != nil {
+        updates["last_error_message"] = syncErr.Error()
+    }
+
+    var count int64
+    err := s.db.Table("blade_items").Where("data_source_id = ? AND deleted_at IS NULL", sourceID).Count(&count).Error
+    if err != nil {
+        log.Printf("Failed to count items of data source %d: %v", sourceID, err)
+    } else {
+        updates["item_count"] = count
+    }
+
+    err = s.db.Model(&datasource.DataSource{}).
+        Where("id = ?", sourceID).
+        Updates(updates).Error
+    if err != nil {
+        log.Printf("Failed to record sync status for data source %d: %v", sourceID, err)
+    }
+}
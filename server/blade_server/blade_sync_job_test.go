@@ -0,0 +1,304 @@
+package blade_server
+
+import (
+    "context"
+    "database/sql/driver"
+    "net/http"
+    "net/http/httptest"
+    "slices"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// syncDataTypes are the data types a sync server is configured with
+var syncDataTypes = []string{"maintenance", "sortie", "logistics"}
+
+// newSyncServer returns a recording Server reading rows from warehouse
+// through one data source per data type, maintenance's being data source 1,
+// and uploading to a catalog that accepts everything one item at a time.
+// Local items are known as item ID to data source ID.
+func newSyncServer(t *testing.T, warehouse *fakeWarehouse, known map[string]int64) (*Server, *recordingDB) {
+    catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if strings.HasSuffix(r.URL.Path, "/batch") {
+            http.NotFound(w, r)
+            return
+        }
+        w.Write([]byte(`{"catalog_id":"cat-1"}`))
+    }))
+    t.Cleanup(catalog.Close)
+
+    s, rec := newRecordingServer(t, &utils.Config{
+        MockDatabricksURL:  warehouse.start(t),
+        CatalogURL:         catalog.URL,
+        MaxRecordsPerQuery: 100,
+        CatalogBatchSize:   10,
+        ConcurrentUploads:  1,
+        SyncConcurrency:    2,
+        BLADEDataTypes:     syncDataTypes,
+    })
+    rec.rowsFor = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+        switch {
+        case strings.Contains(query, `FROM "data_sources"`):
+            var dataType string
+            var id int64
+            if sourceID, ok := args[0].(uint); ok {
+                id = int64(sourceID)
+                dataType = syncDataTypes[id-1]
+            } else {
+                dataType = args[0].(string)
+                id = int64(slices.Index(syncDataTypes, dataType) + 1)
+            }
+            return []string{"id", "type_name", "data_type", "enabled", "table_name"},
+                [][]driver.Value{{id, dataType + "-source", dataType, true, dataType + "_v2"}}
+        case strings.Contains(query, `INSERT INTO "ingestion_jobs"`):
+            return []string{"id"}, [][]driver.Value{{int64(1)}}
+        case strings.HasPrefix(query, `SELECT "item_id","data_source_id" FROM "blade_items"`):
+            var rows [][]driver.Value
+            for itemID, sourceID := range known {
+                rows = append(rows, []driver.Value{itemID, sourceID})
+            }
+            return []string{"item_id", "data_source_id"}, rows
+        }
+        return nil, nil
+    }
+    return s, rec
+}
+
+// tombstoned returns the items soft-deleted by a sync
+func tombstoned(rec *recordingDB) []string {
+    var itemIDs []string
+    for _, st := range rec.matching(`UPDATE "blade_items" SET "deleted_at"=`, `WHERE item_id = `) {
+        itemIDs = append(itemIDs, st.Args[1].(string))
+    }
+    return itemIDs
+}
+
+func TestFullSyncTombstonesItemsMissingFromItsSource(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"item_id"}, rows: [][]interface{}{{"M-1"}, {"M-2"}, {"M-5"}}}
+    s, rec := newSyncServer(t, warehouse, map[string]int64{
+        "M-1": 1, "M-2": 1, "M-4": 1,
+        "M-3": 2, // from another data source
+        "M-6": 0, // ingested without one, and not adopted by this fake
+    })
+    job := newSyncJob("job-1", syncJobParameters{SyncType: "FULL", DataTypes: []string{"maintenance"}})
+
+    require.NoError(t, s.syncDataType(context.Background(), job, "maintenance"))
+    assert.Equal(t, []string{"M-4"}, tombstoned(rec))
+    assert.Equal(t, "SELECT * FROM maintenance_v2 ORDER BY item_id ASC", warehouse.statements[0])
+
+    // Items ingested before the type had a source are assigned to its only
+    // source before the known items are listed, so they can be tombstoned
+    adopt := rec.position(`UPDATE "blade_items" SET "data_source_id"=$1`, 0)
+    require.GreaterOrEqual(t, adopt, 0)
+    assert.Greater(t, rec.position(`SELECT "item_id","data_source_id" FROM "blade_items"`, 0), adopt)
+    adoptions := rec.matching(`UPDATE "blade_items" SET "data_source_id"=$1`, `(data_source_id IS NULL OR data_source_id = 0)`)
+    require.Len(t, adoptions, 1)
+    assert.Equal(t, uint(1), adoptions[0].Args[0])
+
+    // The changes are reported as fields of the sync status
+    s.currentSync = job
+    resp, err := s.GetSyncStatus(context.Background(), &emptypb.Empty{})
+    require.NoError(t, err)
+    assert.Equal(t, int32(1), resp.GetItemsAdded())
+    assert.Equal(t, int32(2), resp.GetItemsUpdated())
+    assert.Equal(t, int32(0), resp.GetItemsUnchanged())
+    assert.Equal(t, int32(1), resp.GetItemsDeleted())
+}
+
+func TestSyncWithoutSourceTombstonesNothing(t *testing.T) {
+    s, rec := newSyncServer(t, &fakeWarehouse{}, nil)
+    job := newSyncJob("job-1", syncJobParameters{SyncType: "FULL", DataTypes: []string{"maintenance"}})
+
+    known := map[string]uint{"M-1": 0, "M-2": 0}
+    require.NoError(t, s.tombstoneMissing(context.Background(), job, "maintenance", 0, known, map[string]struct{}{}))
+    assert.Empty(t, tombstoned(rec))
+    assert.Equal(t, int32(0), job.changes.Deleted)
+}
+
+func TestSyncReadsTheRequestedDataSource(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"item_id"}, rows: [][]interface{}{{"M-1"}}}
+    s, rec := newSyncServer(t, warehouse, nil)
+
+    // An archive source of maintenance data, besides the enabled one
+    sources := rec.rowsFor
+    rec.rowsFor = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+        if strings.Contains(query, `FROM "data_sources"`) && args[0] == uint(7) {
+            return []string{"id", "type_name", "data_type", "enabled", "table_name"},
+                [][]driver.Value{{int64(7), "maintenance-archive", "maintenance", true, "maintenance_archive"}}
+        }
+        return sources(query, args)
+    }
+
+    job, err := s.startSync(&pb.SyncJobRequest{SyncType: pb.SyncJobRequest_FULL, DataSourceId: 7})
+    require.NoError(t, err)
+    waitForSync(t, s)
+
+    assert.Equal(t, []string{"maintenance"}, job.DataTypes)
+    require.NotEmpty(t, warehouse.statements)
+    assert.Equal(t, "SELECT * FROM maintenance_archive ORDER BY item_id ASC", warehouse.statements[0])
+    inserts := rec.matching(`INSERT INTO "blade_items"`)
+    require.Len(t, inserts, 1)
+    assert.Contains(t, inserts[0].Args, uint(7))
+
+    // The source decides the data type
+    _, err = s.startSync(&pb.SyncJobRequest{DataType: "sortie", DataSourceId: 7})
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSyncOnlyTombstonesAfterCompleteRead(t *testing.T) {
+    rows := [][]interface{}{{"M-1"}}
+    for _, tc := range []struct {
+        name      string
+        params    syncJobParameters
+        warehouse *fakeWarehouse
+        wantErr   bool
+    }{
+        {"filtered", syncJobParameters{Filter: "priority = 'HIGH'"}, &fakeWarehouse{columns: []string{"item_id"}, rows: rows}, false},
+        {"limited", syncJobParameters{MaxItems: 10}, &fakeWarehouse{columns: []string{"item_id"}, rows: rows}, false},
+        {"truncated", syncJobParameters{}, &fakeWarehouse{columns: []string{"item_id"}, rows: rows, truncated: true}, false},
+        {"unreadable row", syncJobParameters{}, &fakeWarehouse{columns: []string{"item_id", "quantity"}, types: map[string]string{"quantity": "INT"},
+            rows: [][]interface{}{{"M-1", "1"}, {"M-2", "many"}}}, false},
+        {"failed query", syncJobParameters{}, &fakeWarehouse{state: StatementFailed}, true},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            s, rec := newSyncServer(t, tc.warehouse, map[string]int64{"M-1": 1, "M-2": 1})
+            tc.params.SyncType = "FULL"
+            tc.params.DataTypes = []string{"maintenance"}
+            job := newSyncJob("job-1", tc.params)
+
+            err := s.syncDataType(context.Background(), job, "maintenance")
+            if tc.wantErr {
+                assert.Error(t, err)
+            } else {
+                assert.NoError(t, err)
+            }
+            assert.Empty(t, tombstoned(rec), "M-2 may still exist in the source")
+            assert.Equal(t, int32(0), job.changes.Deleted)
+        })
+    }
+}
+
+// waitForSync waits for the current sync job to finish
+func waitForSync(t *testing.T, s *Server) {
+    select {
+    case <-s.currentSync.done:
+    case <-time.After(5 * time.Second):
+        t.Fatal("sync job did not finish")
+    }
+}
+
+func TestSyncRunsDataTypesConcurrentlyUpToLimit(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"item_id"}, rows: [][]interface{}{{"X-1"}, {"X-2"}}}
+    s, _ := newSyncServer(t, warehouse, nil)
+
+    // Queries are held until as many as SyncConcurrency are in flight
+    var mu sync.Mutex
+    inFlight, most := 0, 0
+    full := make(chan struct{})
+    release := make(chan struct{})
+    var once sync.Once
+    warehouse.onQuery = func(string) {
+        mu.Lock()
+        inFlight++
+        most = max(most, inFlight)
+        if inFlight == 2 {
+            once.Do(func() { close(full) })
+        }
+        mu.Unlock()
+
+        select {
+        case <-release:
+        case <-time.After(5 * time.Second):
+        }
+        mu.Lock()
+        inFlight--
+        mu.Unlock()
+    }
+
+    _, err := s.StartBLADESync(context.Background(), &pb.SyncJobRequest{SyncType: pb.SyncJobRequest_FULL})
+    require.NoError(t, err)
+
+    select {
+    case <-full:
+    case <-time.After(5 * time.Second):
+        t.Fatal("data types were not synced concurrently")
+    }
+    resp, err := s.GetSyncStatus(context.Background(), &emptypb.Empty{})
+    require.NoError(t, err)
+    assert.Equal(t, "maintenance: querying data; sortie: querying data", resp.GetCurrentOperation())
+    close(release)
+    waitForSync(t, s)
+
+    resp, err = s.GetSyncStatus(context.Background(), &emptypb.Empty{})
+    require.NoError(t, err)
+    assert.Equal(t, 2, most)
+    assert.Equal(t, JobStatusCompleted, resp.GetStatus())
+    assert.Equal(t, map[string]int32{"maintenance": 2, "sortie": 2, "logistics": 2}, resp.GetProgressByType())
+    assert.Equal(t, int32(6), resp.GetProcessedItems())
+    assert.Equal(t, int32(6), resp.GetSuccessCount())
+}
+
+func TestStoppedSyncIsRecordedAsCancelled(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"item_id"}, rows: [][]interface{}{{"M-1"}}}
+    s, rec := newSyncServer(t, warehouse, nil)
+
+    // The sync is stopped while its query runs
+    warehouse.onQuery = func(string) {
+        _, err := s.StopBLADESync(context.Background(), &emptypb.Empty{})
+        assert.NoError(t, err)
+    }
+
+    _, err := s.StartBLADESync(context.Background(), &pb.SyncJobRequest{SyncType: pb.SyncJobRequest_DATA_TYPE, DataType: "maintenance"})
+    require.NoError(t, err)
+    waitForSync(t, s)
+
+    resp, err := s.GetSyncStatus(context.Background(), &emptypb.Empty{})
+    require.NoError(t, err)
+    assert.Equal(t, JobStatusCancelled, resp.GetStatus())
+    assert.Zero(t, resp.GetErrorCount())
+    assert.Empty(t, resp.GetRecentErrors())
+    assert.False(t, s.currentSync.typeState("maintenance").Failed)
+
+    // The outcome is recorded on the data source that was read
+    counts := rec.matching(`FROM "blade_items" WHERE data_source_id = `)
+    require.Len(t, counts, 1)
+    assert.Equal(t, uint(1), counts[0].Args[0])
+    updates := rec.matching(`UPDATE "data_sources" SET`, `WHERE id = `)
+    require.Len(t, updates, 1)
+    assert.Contains(t, updates[0].Args, JobStatusCancelled)
+    assert.Equal(t, uint(1), updates[0].Args[len(updates[0].Args)-1])
+}
+
+func TestRecoverSyncJobWithCorruptCheckpointFails(t *testing.T) {
+    s, rec := newRecordingServer(t, &utils.Config{JobMaxResumes: 3, BLADEDataTypes: syncDataTypes})
+    rec.rowsFor = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+        if strings.Contains(query, `FROM "ingestion_jobs"`) {
+            return []string{"id", "job_id", "job_type", "status", "parameters", "checkpoint"},
+                [][]driver.Value{{int64(4), "sync-4", models.JobTypeSync, JobStatusRunning,
+                    []byte(`{"sync_type":"FULL","data_types":["maintenance"]}`), []byte(`{"types":`)}}
+        }
+        return nil, nil
+    }
+
+    require.NoError(t, s.recoverSyncJobs())
+
+    // The job fails instead of starting over, and is not counted as resumed
+    assert.Nil(t, s.currentSync)
+    failed := rec.matching(`UPDATE "ingestion_jobs" SET`, `AND "id" = `)
+    require.Len(t, failed, 1)
+    assert.Contains(t, failed[0].Args, JobStatusFailed)
+    assert.Contains(t, failed[0].Args, []byte(`["cannot resume job: invalid checkpoint: unexpected end of JSON input"]`))
+    assert.NotContains(t, failed[0].SQL, "resume_count")
+}
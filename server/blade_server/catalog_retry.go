@@ -0,0 +1,207 @@
+package blade_server
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "math/rand"
+    "net"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+const (
+    defaultRetryDelay    = time.Second
+    defaultMaxRetryDelay = 30 * time.Second
+
+    // maxErrorBodySize bounds how much of an error response is kept
+    maxErrorBodySize = 4096
+)
+
+// StatusError is a non-success response from the catalog or another sink endpoint
+type StatusError struct {
+    Target     string
+    StatusCode int
+    Body       string
+    RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+    return fmt.Sprintf("%s returned status %d: %s", e.Target, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may succeed if sent again. Rate
+// limiting, timeouts and server errors are transient; other 4xx responses
+// and 501 Not Implemented are permanent.
+func (e *StatusError) Retryable() bool {
+    switch {
+    case e.StatusCode == http.StatusTooManyRequests, e.StatusCode == http.StatusRequestTimeout:
+        return true
+    case e.StatusCode == http.StatusNotImplemented:
+        return false
+    default:
+        return e.StatusCode >= 500
+    }
+}
+
+// retryPolicy schedules retries with exponential backoff and jitter
+type retryPolicy struct {
+    maxRetries int
+    baseDelay  time.Duration
+    maxDelay   time.Duration
+}
+
+func newRetryPolicy(maxRetries int, baseDelay, maxDelay time.Duration) *retryPolicy {
+    if maxRetries < 0 {
+        maxRetries = 0
+    }
+    if baseDelay <= 0 {
+        baseDelay = defaultRetryDelay
+    }
+    if maxDelay <= 0 {
+        maxDelay = defaultMaxRetryDelay
+    }
+    if maxDelay < baseDelay {
+        maxDelay = baseDelay
+    }
+    return &retryPolicy{maxRetries: maxRetries, baseDelay: baseDelay, maxDelay: maxDelay}
+}
+
+// delay returns how long to wait before retry number attempt+1. A server
+// supplied Retry-After takes precedence; if it exceeds the maximum delay the
+// second return value is false and the request should not be retried.
+func (p *retryPolicy) delay(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+    if retryAfter > 0 {
+        return retryAfter, retryAfter <= p.maxDelay
+    }
+
+    backoff := p.maxDelay
+    if attempt < 30 {
+        if d := p.baseDelay << uint(attempt); d > 0 && d < p.maxDelay {
+            backoff = d
+        }
+    }
+
+    // Equal jitter: half fixed, half random, so concurrent uploads spread out
+    half := backoff / 2
+    return half + time.Duration(rand.Int63n(int64(half)+1)), true
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+    if value == "" {
+        return 0
+    }
+    if secs, err := strconv.Atoi(value); err == nil {
+        if secs < 0 {
+            return 0
+        }
+        return time.Duration(secs) * time.Second
+    }
+    if at, err := http.ParseTime(value); err == nil && at.After(now) {
+        return at.Sub(now)
+    }
+    return 0
+}
+
+// send sends a request to the catalog, retrying transient failures. It
+// returns the successful response, the number of retries made and, on
+// failure, the last error.
+func (cu *CatalogUploader) send(ctx context.Context, method, path, contentType string, body []byte) (*http.Response, int, error) {
+    return sendWithRetry(ctx, cu.httpClient, cu.retry, "catalog", func() (*http.Request, error) {
+        req, err := http.NewRequestWithContext(ctx, method, cu.catalogURL+path, bytes.NewReader(body))
+        if err != nil {
+            return nil, err
+        }
+        req.Header.Set("Authorization", "Bearer "+cu.authToken)
+        if contentType != "" {
+            req.Header.Set("Content-Type", contentType)
+        }
+        return req, nil
+    })
+}
+
+// sendWithRetry sends the request built by newRequest until it succeeds, fails
+// permanently or runs out of retries. newRequest is called once per attempt.
+// A POST may have been processed even though its response was lost, so it
+// is only retried when the server cannot have acted on it: the connection
+// was never made, or the server answered 429 or 503.
+func sendWithRetry(ctx context.Context, client *http.Client, policy *retryPolicy, target string, newRequest func() (*http.Request, error)) (*http.Response, int, error) {
+    for attempt := 0; ; attempt++ {
+        req, err := newRequest()
+        if err != nil {
+            return nil, attempt, fmt.Errorf("failed to create request: %w", err)
+        }
+
+        retryable := true
+        var retryAfter time.Duration
+
+        resp, err := client.Do(req)
+        if err == nil {
+            if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+                return resp, attempt, nil
+            }
+            bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+            resp.Body.Close()
+
+            statusErr := &StatusError{
+                Target:     target,
+                StatusCode: resp.StatusCode,
+                Body:       string(bodyBytes),
+                RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+            }
+            err, retryable, retryAfter = statusErr, statusErr.Retryable(), statusErr.RetryAfter
+            if !idempotentMethod(req.Method) {
+                retryable = statusErr.StatusCode == http.StatusTooManyRequests ||
+                    statusErr.StatusCode == http.StatusServiceUnavailable
+            }
+        } else {
+            retryable = idempotentMethod(req.Method) || notSent(err)
+            err = fmt.Errorf("failed to send request: %w", err)
+        }
+
+        if ctx.Err() != nil || !retryable || attempt >= policy.maxRetries {
+            return nil, attempt, err
+        }
+        wait, ok := policy.delay(attempt, retryAfter)
+        if !ok {
+            return nil, attempt, fmt.Errorf("%w (Retry-After %s exceeds maximum retry delay)", err, wait)
+        }
+
+        log.Printf("%s %s %s failed (attempt %d/%d), retrying in %s: %v",
+            target, req.Method, req.URL.Path, attempt+1, policy.maxRetries+1, wait.Round(time.Millisecond), err)
+
+        timer := time.NewTimer(wait)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return nil, attempt, err
+        case <-timer.C:
+        }
+    }
+}
+
+// idempotentMethod reports whether sending a request twice has the same
+// effect as sending it once
+func idempotentMethod(method string) bool {
+    switch method {
+    case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+        return true
+    }
+    return false
+}
+
+// notSent reports whether a transport error happened before the request
+// reached the server, such as a failed lookup or a refused connection
+func notSent(err error) bool {
+    var opErr *net.OpError
+    if errors.As(err, &opErr) && opErr.Op == "dial" {
+        return true
+    }
+    var dnsErr *net.DNSError
+    return errors.As(err, &dnsErr)
+}
@@ -1,16 +1,15 @@
 package blade_server
 
 import (
-    "bytes"
     "context"
     "encoding/json"
     "fmt"
-    "io"
     "net/http"
+    "regexp"
+    "strconv"
     "time"
     
     "blade-ingestion-service/database/models"
-    pb "blade-ingestion-service/generated/proto"
     
     "google.golang.org/grpc/codes"
     "google.golang.org/grpc/status"
@@ -22,6 +21,13 @@ type DatabricksClient struct {
     token       string
     warehouseID string
     httpClient  *http.Client
+    
+    // Result disposition requested for statements (INLINE or EXTERNAL_LINKS)
+    disposition string
+    
+    // Statement polling intervals, doubled after each poll up to the maximum
+    pollInterval    time.Duration
+    maxPollInterval time.Duration
 }
 
 // NewDatabricksClient creates a new Databricks client
@@ -33,96 +39,130 @@ func NewDatabricksClient(baseURL, token, warehouseID string) *DatabricksClient {
         httpClient: &http.Client{
             Timeout: 30 * time.Second,
         },
+        disposition:     DispositionInline,
+        pollInterval:    500 * time.Millisecond,
+        maxPollInterval: 5 * time.Second,
     }
 }
 
-// ExecuteQuery executes a SQL query against Databricks
-func (dc *DatabricksClient) ExecuteQuery(ctx context.Context, query string) ([]map[string]interface{}, error) {
-    // Prepare request
-    reqBody := map[string]interface{}{
-        "warehouse_id": dc.warehouseID,
-        "statement":    query,
-        "wait_timeout": "30s",
-    }
-    
-    jsonData, err := json.Marshal(reqBody)
-    if err != nil {
-        return nil, fmt.Errorf("failed to marshal request: %w", err)
+var (
+    parameterNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+    tableNamePattern     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*){0,2}$`)
+)
+
+// StatementParameter is a value bound to a :name marker in a SQL statement.
+// A nil Value binds SQL NULL.
+type StatementParameter struct {
+    Name  string  `json:"name"`
+    Value *string `json:"value"`
+    Type  string  `json:"type,omitempty"`
+}
+
+// NewParameter creates a statement parameter, inferring the Databricks type
+func NewParameter(name string, value interface{}) StatementParameter {
+    param := StatementParameter{Name: name}
+
+    var str string
+    switch v := value.(type) {
+    case nil:
+        return param
+    case string:
+        str, param.Type = v, "STRING"
+    case bool:
+        str, param.Type = strconv.FormatBool(v), "BOOLEAN"
+    case int:
+        str, param.Type = strconv.Itoa(v), "BIGINT"
+    case int32:
+        str, param.Type = strconv.FormatInt(int64(v), 10), "BIGINT"
+    case int64:
+        str, param.Type = strconv.FormatInt(v, 10), "BIGINT"
+    case float32:
+        str, param.Type = strconv.FormatFloat(float64(v), 'f', -1, 32), "DOUBLE"
+    case float64:
+        str, param.Type = strconv.FormatFloat(v, 'f', -1, 64), "DOUBLE"
+    case time.Time:
+        str, param.Type = v.UTC().Format(time.RFC3339Nano), "TIMESTAMP"
+    default:
+        str, param.Type = fmt.Sprint(v), "STRING"
     }
-    
-    // Create HTTP request
-    req, err := http.NewRequestWithContext(ctx, "POST", 
-        fmt.Sprintf("%s/api/2.0/sql/statements", dc.baseURL), 
-        bytes.NewBuffer(jsonData))
-    if err != nil {
-        return nil, fmt.Errorf("failed to create request: %w", err)
+
+    param.Value = &str
+    return param
+}
+
+// validateParameters checks parameter names are well formed and unique
+func validateParameters(params []StatementParameter) error {
+    seen := make(map[string]bool, len(params))
+    for _, p := range params {
+        if !parameterNamePattern.MatchString(p.Name) {
+            return fmt.Errorf("invalid parameter name %q", p.Name)
+        }
+        if seen[p.Name] {
+            return fmt.Errorf("duplicate parameter %q", p.Name)
+        }
+        seen[p.Name] = true
     }
-    
-    // Set headers
-    req.Header.Set("Authorization", "Bearer "+dc.token)
-    req.Header.Set("Content-Type", "application/json")
-    
-    // Execute request
-    resp, err := dc.httpClient.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("failed to execute query: %w", err)
+    return nil
+}
+
+// ValidateTableName checks that a table name is a plain, optionally
+// qualified identifier, since table names cannot be bound as parameters
+func ValidateTableName(tableName string) error {
+    if !tableNamePattern.MatchString(tableName) {
+        return fmt.Errorf("invalid table name %q", tableName)
     }
-    defer resp.Body.Close()
-    
-    // Read response
-    body, err := io.ReadAll(resp.Body)
+    return nil
+}
+
+// ExecuteQuery executes a SQL query against Databricks and loads every row
+// into memory; use StreamQuery for large results. Values must be passed as
+// params and referenced by :name markers, never spliced into the query.
+func (dc *DatabricksClient) ExecuteQuery(ctx context.Context, query string, params ...StatementParameter) ([]map[string]interface{}, error) {
+    it, err := dc.StreamQuery(ctx, query, params...)
     if err != nil {
-        return nil, fmt.Errorf("failed to read response: %w", err)
-    }
-    
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("databricks returned status %d: %s", resp.StatusCode, string(body))
-    }
-    
-    // Parse response
-    var result struct {
-        Result struct {
-            Data [][]interface{} `json:"data"`
-            Schema struct {
-                Columns []struct {
-                    Name string `json:"name"`
-                } `json:"columns"`
-            } `json:"schema"`
-        } `json:"result"`
-    }
-    
-    if err := json.Unmarshal(body, &result); err != nil {
-        return nil, fmt.Errorf("failed to parse response: %w", err)
+        return nil, err
     }
+    defer it.Close()
     
-    // Convert to map format
     var rows []map[string]interface{}
-    columns := result.Result.Schema.Columns
-    
-    for _, row := range result.Result.Data {
-        rowMap := make(map[string]interface{})
-        for i, col := range columns {
-            if i < len(row) {
-                rowMap[col.Name] = row[i]
-            }
+    for it.Next() {
+        row, err := it.Row()
+        if err != nil {
+            return nil, err
         }
-        rows = append(rows, rowMap)
+        rows = append(rows, row)
+    }
+    if err := it.Err(); err != nil {
+        return nil, err
     }
     
     return rows, nil
 }
 
+// SetResultDisposition selects INLINE or EXTERNAL_LINKS result delivery
+func (dc *DatabricksClient) SetResultDisposition(disposition string) error {
+    switch disposition {
+    case DispositionInline, DispositionExternalLinks:
+        dc.disposition = disposition
+        return nil
+    }
+    return fmt.Errorf("unsupported result disposition %q", disposition)
+}
+
 // FetchBLADEItem fetches a specific BLADE item
 func (dc *DatabricksClient) FetchBLADEItem(ctx context.Context, dataType, itemID string, tableName string) (map[string]interface{}, error) {
-    query := fmt.Sprintf("SELECT * FROM %s WHERE item_id = '%s' LIMIT 1", tableName, itemID)
+    if err := ValidateTableName(tableName); err != nil {
+        return nil, err
+    }
+    query := fmt.Sprintf("SELECT * FROM %s WHERE item_id = :item_id LIMIT 1", tableName)
     
-    rows, err := dc.ExecuteQuery(ctx, query)
+    rows, err := dc.ExecuteQuery(ctx, query, NewParameter("item_id", itemID))
     if err != nil {
         return nil, err
     }
     
     if len(rows) == 0 {
-        return nil, fmt.Errorf("item not found")
+        return nil, status.Errorf(codes.NotFound, "item %s not found", itemID)
     }
     
     return rows[0], nil
@@ -156,6 +196,9 @@ func TransformToBLADEItem(dataType string, rawData map[string]interface{}) (*mod
         ClassificationMarking: classification,
         LastModified:          time.Now(),
     }
+    if modified, ok := rawData["last_modified"].(time.Time); ok {
+        item.LastModified = modified
+    }
     
     // Add metadata
     metadata := map[string]interface{}{
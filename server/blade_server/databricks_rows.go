@@ -0,0 +1,151 @@
+package blade_server
+
+import (
+    "context"
+    "fmt"
+)
+
+// RowIterator streams the rows of a statement result one chunk at a time,
+// so memory use is bounded by the chunk size rather than the result size.
+//
+//     it, err := client.StreamQuery(ctx, query, params...)
+//     if err != nil { ... }
+//     defer it.Close()
+//     for it.Next() {
+//         row, err := it.Row()
+//     }
+//     if err := it.Err(); err != nil { ... }
+type RowIterator struct {
+    ctx         context.Context
+    client      *DatabricksClient
+    statementID string
+    columns     []statementColumn
+    totalRows   int64
+    truncated   bool
+
+    // Rows of the current chunk and the position within them
+    rows [][]interface{}
+    pos  int
+
+    // Work remaining after the current chunk
+    pendingLinks []externalLink
+    nextLink     string
+
+    current []interface{}
+    err     error
+    closed  bool
+}
+
+// StreamQuery executes a query and returns an iterator over its rows
+func (dc *DatabricksClient) StreamQuery(ctx context.Context, query string, params ...StatementParameter) (*RowIterator, error) {
+    if err := validateParameters(params); err != nil {
+        return nil, err
+    }
+
+    stmt, err := dc.runStatement(ctx, query, params)
+    if err != nil {
+        return nil, err
+    }
+
+    it := &RowIterator{
+        ctx:         ctx,
+        client:      dc,
+        statementID: stmt.StatementID,
+        columns:     stmt.columns(),
+        totalRows:   stmt.Manifest.TotalRowCount,
+        truncated:   stmt.Manifest.Truncated,
+    }
+    it.load(&stmt.Result)
+
+    if it.totalRows == 0 && it.nextLink == "" && len(it.pendingLinks) == 0 {
+        it.totalRows = int64(len(it.rows))
+    }
+    return it, nil
+}
+
+// load queues the rows and links of a result chunk
+func (it *RowIterator) load(chunk *statementResult) {
+    it.rows = chunk.Data
+    it.pos = 0
+    it.pendingLinks = append(it.pendingLinks, chunk.ExternalLinks...)
+    it.nextLink = chunk.nextLink()
+}
+
+// Next advances to the next row, fetching further chunks as needed
+func (it *RowIterator) Next() bool {
+    if it.err != nil || it.closed {
+        return false
+    }
+
+    for it.pos >= len(it.rows) {
+        if err := it.ctx.Err(); err != nil {
+            it.err = err
+            return false
+        }
+
+        switch {
+        case len(it.pendingLinks) > 0:
+            link := it.pendingLinks[0]
+            it.pendingLinks = it.pendingLinks[1:]
+            rows, err := it.client.fetchExternalLink(it.ctx, link)
+            if err != nil {
+                it.err = fmt.Errorf("statement %s: %w", it.statementID, err)
+                return false
+            }
+            it.rows, it.pos = rows, 0
+
+        case it.nextLink != "":
+            chunk, err := it.client.fetchChunk(it.ctx, it.nextLink)
+            if err != nil {
+                it.err = fmt.Errorf("statement %s: failed to fetch chunk: %w", it.statementID, err)
+                return false
+            }
+            it.load(chunk)
+
+        default:
+            return false
+        }
+    }
+
+    it.current = it.rows[it.pos]
+    it.pos++
+    return true
+}
+
+// Row returns the current row keyed by column name, with values decoded
+// according to the column types in the result manifest
+func (it *RowIterator) Row() (map[string]interface{}, error) {
+    return decodeRow(it.columns, it.current)
+}
+
+// Columns returns the names of the result's columns in order
+func (it *RowIterator) Columns() []string {
+    names := make([]string, len(it.columns))
+    for i, col := range it.columns {
+        names[i] = col.Name
+    }
+    return names
+}
+
+// Err returns the error that stopped iteration, if any
+func (it *RowIterator) Err() error {
+    return it.err
+}
+
+// TotalRows returns the row count reported by the result manifest
+func (it *RowIterator) TotalRows() int64 {
+    return it.totalRows
+}
+
+// Truncated reports whether the warehouse truncated the result
+func (it *RowIterator) Truncated() bool {
+    return it.truncated
+}
+
+// Close releases the iterator; remaining chunks are not fetched
+func (it *RowIterator) Close() {
+    it.closed = true
+    it.rows = nil
+    it.pendingLinks = nil
+    it.nextLink = ""
+}
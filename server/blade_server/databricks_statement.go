@@ -0,0 +1,329 @@
+package blade_server
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "time"
+)
+
+// Result dispositions
+const (
+    DispositionInline        = "INLINE"
+    DispositionExternalLinks = "EXTERNAL_LINKS"
+)
+
+// Databricks SQL statement states
+const (
+    StatementPending   = "PENDING"
+    StatementRunning   = "RUNNING"
+    StatementSucceeded = "SUCCEEDED"
+    StatementFailed    = "FAILED"
+    StatementCanceled  = "CANCELED"
+    StatementClosed    = "CLOSED"
+)
+
+// statementWaitTimeout is how long the server holds the submit call open
+// before returning a PENDING or RUNNING statement for us to poll. It must
+// stay well below the HTTP client timeout.
+const statementWaitTimeout = "10s"
+
+// cancelTimeout bounds the best-effort cancel call made after ctx ends
+const cancelTimeout = 10 * time.Second
+
+// StatementError reports a statement that did not succeed
+type StatementError struct {
+    StatementID string
+    State       string
+    ErrorCode   string
+    Message     string
+}
+
+func (e *StatementError) Error() string {
+    msg := fmt.Sprintf("statement %s %s", e.StatementID, e.State)
+    if e.ErrorCode != "" {
+        msg += ": " + e.ErrorCode
+    }
+    if e.Message != "" {
+        msg += ": " + e.Message
+    }
+    return msg
+}
+
+// statementColumn describes one column of a statement result
+type statementColumn struct {
+    Name          string `json:"name"`
+    Position      int    `json:"position"`
+    TypeName      string `json:"type_name"`
+    TypeText      string `json:"type_text"`
+    TypePrecision int    `json:"type_precision"`
+    TypeScale     int    `json:"type_scale"`
+}
+
+// externalLink is a presigned URL holding one chunk of an EXTERNAL_LINKS result
+type externalLink struct {
+    ChunkIndex            int    `json:"chunk_index"`
+    ExternalLink          string `json:"external_link"`
+    NextChunkIndex        *int   `json:"next_chunk_index,omitempty"`
+    NextChunkInternalLink string `json:"next_chunk_internal_link,omitempty"`
+}
+
+// statementResult is one chunk of a statement result
+type statementResult struct {
+    ChunkIndex            int             `json:"chunk_index"`
+    Data                  [][]interface{} `json:"data_array"`
+    NextChunkIndex        *int            `json:"next_chunk_index,omitempty"`
+    NextChunkInternalLink string          `json:"next_chunk_internal_link,omitempty"`
+    ExternalLinks         []externalLink  `json:"external_links,omitempty"`
+
+    // The mock server uses "data" and an inline schema
+    LegacyData [][]interface{} `json:"data"`
+    Schema     struct {
+        Columns []statementColumn `json:"columns"`
+    } `json:"schema"`
+}
+
+// normalize folds the legacy data field into Data
+func (r *statementResult) normalize() {
+    if r.Data == nil {
+        r.Data = r.LegacyData
+    }
+}
+
+// nextLink returns the internal link of the chunk after this one
+func (r *statementResult) nextLink() string {
+    if r.NextChunkInternalLink != "" {
+        return r.NextChunkInternalLink
+    }
+    if n := len(r.ExternalLinks); n > 0 {
+        return r.ExternalLinks[n-1].NextChunkInternalLink
+    }
+    return ""
+}
+
+// statementResponse is the Statement Execution API response body
+type statementResponse struct {
+    StatementID string `json:"statement_id"`
+    Status      struct {
+        State string `json:"state"`
+        Error *struct {
+            ErrorCode string `json:"error_code"`
+            Message   string `json:"message"`
+        } `json:"error,omitempty"`
+    } `json:"status"`
+    Manifest struct {
+        TotalChunkCount int   `json:"total_chunk_count"`
+        TotalRowCount   int64 `json:"total_row_count"`
+        Truncated       bool  `json:"truncated"`
+        Schema          struct {
+            Columns []statementColumn `json:"columns"`
+        } `json:"schema"`
+    } `json:"manifest"`
+    Result statementResult `json:"result"`
+}
+
+// state returns the statement state. Responses without a status come from
+// servers that only answer synchronously and are treated as finished.
+func (r *statementResponse) state() string {
+    if r.Status.State == "" {
+        return StatementSucceeded
+    }
+    return r.Status.State
+}
+
+// terminal reports whether the statement has stopped executing
+func (r *statementResponse) terminal() bool {
+    switch r.state() {
+    case StatementPending, StatementRunning:
+        return false
+    }
+    return true
+}
+
+// columns returns the result columns from the manifest or inline schema
+func (r *statementResponse) columns() []statementColumn {
+    if len(r.Manifest.Schema.Columns) > 0 {
+        return r.Manifest.Schema.Columns
+    }
+    return r.Result.Schema.Columns
+}
+
+// err converts a non-successful terminal state into an error
+func (r *statementResponse) err() error {
+    if r.state() == StatementSucceeded {
+        return nil
+    }
+    stmtErr := &StatementError{StatementID: r.StatementID, State: r.state()}
+    if r.Status.Error != nil {
+        stmtErr.ErrorCode = r.Status.Error.ErrorCode
+        stmtErr.Message = r.Status.Error.Message
+    }
+    return stmtErr
+}
+
+// runStatement submits a statement and waits for it to reach a terminal
+// state. If ctx ends first the statement is cancelled on the warehouse.
+func (dc *DatabricksClient) runStatement(ctx context.Context, query string, params []StatementParameter) (*statementResponse, error) {
+    reqBody := map[string]interface{}{
+        "warehouse_id":    dc.warehouseID,
+        "statement":       query,
+        "wait_timeout":    statementWaitTimeout,
+        "on_wait_timeout": "CONTINUE",
+        "disposition":     dc.disposition,
+        "format":          "JSON_ARRAY",
+    }
+    if len(params) > 0 {
+        reqBody["parameters"] = params
+    }
+
+    stmt, err := dc.doStatementRequest(ctx, http.MethodPost, "/api/2.0/sql/statements", reqBody)
+    if err != nil {
+        return nil, fmt.Errorf("failed to execute query: %w", err)
+    }
+
+    interval := dc.pollInterval
+    for !stmt.terminal() {
+        select {
+        case <-ctx.Done():
+            dc.cancelAfterContextDone(stmt.StatementID)
+            return nil, ctx.Err()
+        case <-time.After(interval):
+        }
+
+        next, err := dc.doStatementRequest(ctx, http.MethodGet, "/api/2.0/sql/statements/"+stmt.StatementID, nil)
+        if err != nil {
+            if ctx.Err() != nil {
+                dc.cancelAfterContextDone(stmt.StatementID)
+                return nil, ctx.Err()
+            }
+            return nil, fmt.Errorf("failed to poll statement %s: %w", stmt.StatementID, err)
+        }
+        stmt = next
+
+        interval *= 2
+        if interval > dc.maxPollInterval {
+            interval = dc.maxPollInterval
+        }
+    }
+
+    if err := stmt.err(); err != nil {
+        return nil, err
+    }
+    return stmt, nil
+}
+
+// CancelStatement requests cancellation of a running statement
+func (dc *DatabricksClient) CancelStatement(ctx context.Context, statementID string) error {
+    _, err := dc.doRequest(ctx, http.MethodPost, "/api/2.0/sql/statements/"+statementID+"/cancel", nil)
+    return err
+}
+
+// cancelAfterContextDone cancels a statement whose caller has gone away
+func (dc *DatabricksClient) cancelAfterContextDone(statementID string) {
+    if statementID == "" {
+        return
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+    defer cancel()
+
+    if err := dc.CancelStatement(ctx, statementID); err != nil {
+        log.Printf("Failed to cancel Databricks statement %s: %v", statementID, err)
+    }
+}
+
+// doStatementRequest performs a statement API call and decodes the response
+func (dc *DatabricksClient) doStatementRequest(ctx context.Context, method, path string, body interface{}) (*statementResponse, error) {
+    data, err := dc.doRequest(ctx, method, path, body)
+    if err != nil {
+        return nil, err
+    }
+
+    var stmt statementResponse
+    if err := json.Unmarshal(data, &stmt); err != nil {
+        return nil, fmt.Errorf("failed to parse response: %w", err)
+    }
+    stmt.Result.normalize()
+    return &stmt, nil
+}
+
+// fetchChunk fetches a result chunk by its internal link
+func (dc *DatabricksClient) fetchChunk(ctx context.Context, link string) (*statementResult, error) {
+    data, err := dc.doRequest(ctx, http.MethodGet, link, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    var chunk statementResult
+    if err := json.Unmarshal(data, &chunk); err != nil {
+        return nil, fmt.Errorf("failed to parse result chunk: %w", err)
+    }
+    chunk.normalize()
+    return &chunk, nil
+}
+
+// fetchExternalLink downloads the rows behind a presigned external link.
+// The URL carries its own credentials, so no Authorization header is sent.
+func (dc *DatabricksClient) fetchExternalLink(ctx context.Context, link externalLink) ([][]interface{}, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.ExternalLink, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %w", err)
+    }
+
+    resp, err := dc.httpClient.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+        return nil, fmt.Errorf("external link for chunk %d returned status %d: %s", link.ChunkIndex, resp.StatusCode, string(data))
+    }
+
+    var rows [][]interface{}
+    if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+        return nil, fmt.Errorf("failed to parse chunk %d: %w", link.ChunkIndex, err)
+    }
+    return rows, nil
+}
+
+// doRequest performs an authenticated Databricks API call
+func (dc *DatabricksClient) doRequest(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+    var reader io.Reader
+    if body != nil {
+        jsonData, err := json.Marshal(body)
+        if err != nil {
+            return nil, fmt.Errorf("failed to marshal request: %w", err)
+        }
+        reader = bytes.NewReader(jsonData)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, method, dc.baseURL+path, reader)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %w", err)
+    }
+    req.Header.Set("Authorization", "Bearer "+dc.token)
+    if body != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+
+    resp, err := dc.httpClient.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    data, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read response: %w", err)
+    }
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("databricks returned status %d: %s", resp.StatusCode, string(data))
+    }
+    return data, nil
+}
@@ -0,0 +1,205 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/models"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestFetchBLADEItemBindsItemID(t *testing.T) {
+    var captured struct {
+        Statement  string               `json:"statement"`
+        Parameters []StatementParameter `json:"parameters"`
+    }
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        json.NewDecoder(r.Body).Decode(&captured)
+        w.Write([]byte(`{"result":{"data":[["M-1"]],"schema":{"columns":[{"name":"item_id"}]}}}`))
+    }))
+    defer server.Close()
+
+    client := NewDatabricksClient(server.URL, "token", "warehouse")
+    maliciousID := "x' OR '1'='1"
+
+    row, err := client.FetchBLADEItem(context.Background(), "maintenance", maliciousID, "public.blade_maintenance_data")
+
+    assert.NoError(t, err)
+    assert.Equal(t, "M-1", row["item_id"])
+    assert.Equal(t, "SELECT * FROM public.blade_maintenance_data WHERE item_id = :item_id LIMIT 1", captured.Statement)
+    assert.NotContains(t, captured.Statement, maliciousID)
+    assert.Len(t, captured.Parameters, 1)
+    assert.Equal(t, "item_id", captured.Parameters[0].Name)
+    assert.Equal(t, maliciousID, *captured.Parameters[0].Value)
+}
+
+func TestFetchBLADEItemRejectsInvalidTable(t *testing.T) {
+    client := NewDatabricksClient("http://unused", "token", "warehouse")
+
+    _, err := client.FetchBLADEItem(context.Background(), "maintenance", "M-1", "items; DROP TABLE items")
+
+    assert.Error(t, err)
+    assert.Contains(t, err.Error(), "invalid table name")
+}
+
+func TestNewParameter(t *testing.T) {
+    p := NewParameter("limit", 10)
+    assert.Equal(t, "BIGINT", p.Type)
+    assert.Equal(t, "10", *p.Value)
+
+    p = NewParameter("flag", true)
+    assert.Equal(t, "BOOLEAN", p.Type)
+
+    p = NewParameter("missing", nil)
+    assert.Nil(t, p.Value)
+
+    assert.Error(t, validateParameters([]StatementParameter{NewParameter("bad name", "x")}))
+    assert.Error(t, validateParameters([]StatementParameter{NewParameter("a", "x"), NewParameter("a", "y")}))
+}
+
+func TestExecuteQueryPollsUntilSucceeded(t *testing.T) {
+    polls := 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch {
+        case r.Method == http.MethodPost:
+            w.Write([]byte(`{"statement_id":"s1","status":{"state":"PENDING"}}`))
+        case r.URL.Path == "/api/2.0/sql/statements/s1" && polls < 2:
+            polls++
+            w.Write([]byte(`{"statement_id":"s1","status":{"state":"RUNNING"}}`))
+        default:
+            w.Write([]byte(`{"statement_id":"s1","status":{"state":"SUCCEEDED"},
+                "manifest":{"schema":{"columns":[{"name":"item_id"}]}},
+                "result":{"data_array":[["M-1"],["M-2"]]}}`))
+        }
+    }))
+    defer server.Close()
+
+    client := NewDatabricksClient(server.URL, "token", "warehouse")
+    client.pollInterval = time.Millisecond
+
+    rows, err := client.ExecuteQuery(context.Background(), "SELECT 1")
+
+    assert.NoError(t, err)
+    assert.Equal(t, 2, polls)
+    assert.Len(t, rows, 2)
+    assert.Equal(t, "M-2", rows[1]["item_id"])
+}
+
+func TestExecuteQueryReportsFailure(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"statement_id":"s1","status":{"state":"FAILED",
+            "error":{"error_code":"BAD_REQUEST","message":"Table not found"}}}`))
+    }))
+    defer server.Close()
+
+    client := NewDatabricksClient(server.URL, "token", "warehouse")
+    _, err := client.ExecuteQuery(context.Background(), "SELECT 1")
+
+    var stmtErr *StatementError
+    assert.ErrorAs(t, err, &stmtErr)
+    assert.Equal(t, StatementFailed, stmtErr.State)
+    assert.Contains(t, err.Error(), "Table not found")
+}
+
+func TestExecuteQueryCancelsOnContextDone(t *testing.T) {
+    cancelled := make(chan string, 1)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if strings.HasSuffix(r.URL.Path, "/cancel") {
+            cancelled <- r.URL.Path
+            w.Write([]byte(`{}`))
+            return
+        }
+        w.Write([]byte(`{"statement_id":"s1","status":{"state":"RUNNING"}}`))
+    }))
+    defer server.Close()
+
+    client := NewDatabricksClient(server.URL, "token", "warehouse")
+    client.pollInterval = time.Millisecond
+
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+
+    _, err := client.ExecuteQuery(ctx, "SELECT 1")
+
+    assert.ErrorIs(t, err, context.DeadlineExceeded)
+    assert.Equal(t, "/api/2.0/sql/statements/s1/cancel", <-cancelled)
+}
+
+func TestStreamQueryFollowsChunks(t *testing.T) {
+    var server *httptest.Server
+    server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/api/2.0/sql/statements":
+            w.Write([]byte(`{"statement_id":"s1","status":{"state":"SUCCEEDED"},
+                "manifest":{"total_chunk_count":3,"total_row_count":5,"schema":{"columns":[{"name":"item_id"}]}},
+                "result":{"chunk_index":0,"data_array":[["A"],["B"]],
+                    "next_chunk_index":1,"next_chunk_internal_link":"/api/2.0/sql/statements/s1/result/chunks/1"}}`))
+        case "/api/2.0/sql/statements/s1/result/chunks/1":
+            w.Write([]byte(`{"chunk_index":1,"external_links":[{"chunk_index":1,
+                "external_link":"` + server.URL + `/presigned/1",
+                "next_chunk_index":2,"next_chunk_internal_link":"/api/2.0/sql/statements/s1/result/chunks/2"}]}`))
+        case "/presigned/1":
+            assert.Empty(t, r.Header.Get("Authorization"))
+            w.Write([]byte(`[["C"],["D"]]`))
+        case "/api/2.0/sql/statements/s1/result/chunks/2":
+            w.Write([]byte(`{"chunk_index":2,"data_array":[["E"]]}`))
+        default:
+            http.NotFound(w, r)
+        }
+    }))
+    defer server.Close()
+
+    client := NewDatabricksClient(server.URL, "token", "warehouse")
+    it, err := client.StreamQuery(context.Background(), "SELECT item_id FROM t")
+    assert.NoError(t, err)
+    defer it.Close()
+
+    var ids []string
+    for it.Next() {
+        row, err := it.Row()
+        assert.NoError(t, err)
+        ids = append(ids, row["item_id"].(string))
+    }
+
+    assert.NoError(t, it.Err())
+    assert.Equal(t, int64(5), it.TotalRows())
+    assert.Equal(t, []string{"A", "B", "C", "D", "E"}, ids)
+}
+
+func TestDecodeRowUsesColumnTypes(t *testing.T) {
+    columns := []statementColumn{
+        {Name: "item_id", TypeName: "STRING"},
+        {Name: "mission_id", TypeName: "STRING"},
+        {Name: "flight_hours", TypeName: "DOUBLE"},
+        {Name: "crew_count", TypeName: "INT"},
+        {Name: "scheduled_departure", TypeName: "TIMESTAMP"},
+        {Name: "cost", TypeName: "DECIMAL", TypePrecision: 38, TypeScale: 2},
+        {Name: "tags", TypeName: "ARRAY"},
+    }
+    values := []interface{}{"SORTIE-1", "M-42", "3.5", "4", "2024-01-15T08:30:00Z", "12345678901234567890.12", `["a","b"]`, nil}
+
+    row, err := decodeRow(columns, values)
+    assert.NoError(t, err)
+    assert.Equal(t, 3.5, row["flight_hours"])
+    assert.Equal(t, int64(4), row["crew_count"])
+    assert.Equal(t, time.Date(2024, 1, 15, 8, 30, 0, 0, time.UTC), row["scheduled_departure"])
+    assert.Equal(t, "12345678901234567890.12", row["cost"])
+    assert.Equal(t, []interface{}{"a", "b"}, row["tags"])
+
+    record, err := DecodeBLADERecord("sortie", row)
+    assert.NoError(t, err)
+    sortie := record.(*models.BLADESortieData)
+    assert.Equal(t, "M-42", sortie.MissionID)
+    assert.Equal(t, 3.5, *sortie.FlightHours)
+    assert.Equal(t, 2024, sortie.ScheduledDeparture.Year())
+
+    _, err = decodeRow([]statementColumn{{Name: "crew_count", TypeName: "INT"}}, []interface{}{"four"})
+    assert.ErrorContains(t, err, "crew_count")
+}
@@ -0,0 +1,178 @@
+package blade_server
+
+import (
+    "encoding/json"
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+    "time"
+
+    "blade-ingestion-service/database/models"
+)
+
+// maxFloatDecimalPrecision is the largest DECIMAL precision decoded to
+// float64; wider decimals are kept as their exact string representation
+const maxFloatDecimalPrecision = 15
+
+// Timestamp layouts produced by Databricks in JSON_ARRAY results
+var timestampLayouts = []string{
+    time.RFC3339Nano,
+    "2006-01-02T15:04:05.999999999",
+    "2006-01-02 15:04:05.999999999Z07:00",
+    "2006-01-02 15:04:05.999999999",
+}
+
+// decodeValue converts a raw JSON_ARRAY value to a Go value using the
+// column's declared type. JSON_ARRAY encodes every non-null value as a
+// string; values that are already typed (as the mock server sends them)
+// are coerced to the same Go types.
+func decodeValue(col statementColumn, raw interface{}) (interface{}, error) {
+    if raw == nil {
+        return nil, nil
+    }
+
+    typeName := strings.ToUpper(col.TypeName)
+    str, isString := raw.(string)
+
+    switch typeName {
+    case "":
+        // No type information; keep the value as received
+        return raw, nil
+
+    case "STRING", "CHAR", "VARCHAR", "BINARY", "INTERVAL":
+        if isString {
+            return str, nil
+        }
+        return fmt.Sprint(raw), nil
+
+    case "BOOLEAN":
+        if b, ok := raw.(bool); ok {
+            return b, nil
+        }
+        return strconv.ParseBool(str)
+
+    case "BYTE", "SHORT", "INT", "LONG":
+        return decodeInt(raw)
+
+    case "FLOAT", "DOUBLE":
+        return decodeFloat(raw)
+
+    case "DECIMAL":
+        if col.TypeScale == 0 && col.TypePrecision > 0 && col.TypePrecision <= 18 {
+            return decodeInt(raw)
+        }
+        if col.TypePrecision > maxFloatDecimalPrecision {
+            if isString {
+                return str, nil
+            }
+            return fmt.Sprint(raw), nil
+        }
+        return decodeFloat(raw)
+
+    case "DATE":
+        if !isString {
+            return nil, fmt.Errorf("expected date string, got %T", raw)
+        }
+        return time.Parse("2006-01-02", str)
+
+    case "TIMESTAMP", "TIMESTAMP_NTZ":
+        if !isString {
+            return nil, fmt.Errorf("expected timestamp string, got %T", raw)
+        }
+        return parseTimestamp(str)
+
+    case "ARRAY", "MAP", "STRUCT":
+        if !isString {
+            return raw, nil
+        }
+        var v interface{}
+        if err := json.Unmarshal([]byte(str), &v); err != nil {
+            return nil, err
+        }
+        return v, nil
+
+    case "NULL":
+        return nil, nil
+    }
+
+    return raw, nil
+}
+
+func decodeInt(raw interface{}) (int64, error) {
+    switch v := raw.(type) {
+    case string:
+        return strconv.ParseInt(v, 10, 64)
+    case float64:
+        if v != math.Trunc(v) {
+            return 0, fmt.Errorf("expected integer, got %v", v)
+        }
+        return int64(v), nil
+    case json.Number:
+        return v.Int64()
+    }
+    return 0, fmt.Errorf("expected integer, got %T", raw)
+}
+
+func decodeFloat(raw interface{}) (float64, error) {
+    switch v := raw.(type) {
+    case string:
+        return strconv.ParseFloat(v, 64)
+    case float64:
+        return v, nil
+    case json.Number:
+        return v.Float64()
+    }
+    return 0, fmt.Errorf("expected number, got %T", raw)
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+    for _, layout := range timestampLayouts {
+        if ts, err := time.Parse(layout, value); err == nil {
+            return ts, nil
+        }
+    }
+    return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+// decodeRow converts a raw result row to a map of typed values
+func decodeRow(columns []statementColumn, values []interface{}) (map[string]interface{}, error) {
+    row := make(map[string]interface{}, len(columns))
+    for i, col := range columns {
+        if i >= len(values) {
+            break
+        }
+        v, err := decodeValue(col, values[i])
+        if err != nil {
+            return nil, fmt.Errorf("column %s (%s): %w", col.Name, col.TypeName, err)
+        }
+        row[col.Name] = v
+    }
+    return row, nil
+}
+
+// DecodeBLADERecord maps a decoded row onto the typed record for a data
+// type, e.g. *models.BLADESortieData for "sortie"
+func DecodeBLADERecord(dataType string, row map[string]interface{}) (interface{}, error) {
+    record := models.NewBLADERecord(models.BLADEItemType(dataType))
+    if record == nil {
+        return nil, fmt.Errorf("unknown data type %q", dataType)
+    }
+    if err := DecodeRecord(row, record); err != nil {
+        return nil, err
+    }
+    return record, nil
+}
+
+// DecodeRecord maps a decoded row onto a typed struct such as
+// models.BLADESortieData, matching columns to the struct's JSON tags
+func DecodeRecord(row map[string]interface{}, out interface{}) error {
+    data, err := json.Marshal(row)
+    if err != nil {
+        return fmt.Errorf("failed to encode row: %w", err)
+    }
+    if err := json.Unmarshal(data, out); err != nil {
+        return fmt.Errorf("failed to decode row: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,263 @@
+package blade_server
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    pb "blade-ingestion-service/generated/proto"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/emptypb"
+    "google.golang.org/protobuf/types/known/structpb"
+    "google.golang.org/protobuf/types/known/timestamppb"
+    "gorm.io/datatypes"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// ListDeadLetters returns a page of dead letters, oldest failure first,
+// without their payloads
+func (s *Server) ListDeadLetters(ctx context.Context, req *pb.DeadLetterListRequest) (*pb.DeadLetterList, error) {
+    if req.GetOffset() < 0 {
+        return nil, status.Error(codes.InvalidArgument, "offset must not be negative")
+    }
+
+    var total int64
+    if err := s.deadLetterQuery(ctx, req).Count(&total).Error; err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to count dead letters: %v", err)
+    }
+    letters, err := s.findDeadLetters(ctx, req, int(req.GetOffset()))
+    if err != nil {
+        return nil, err
+    }
+
+    list := &pb.DeadLetterList{DeadLetters: make([]*pb.DeadLetter, len(letters)), TotalCount: total}
+    for i := range letters {
+        if list.DeadLetters[i], err = toProtoDeadLetter(&letters[i], false); err != nil {
+            return nil, err
+        }
+    }
+    return list, nil
+}
+
+// GetDeadLetter returns one dead letter including its payload
+func (s *Server) GetDeadLetter(ctx context.Context, req *pb.DeadLetterRequest) (*pb.DeadLetter, error) {
+    letter, err := s.loadDeadLetter(ctx, req.GetId())
+    if err != nil {
+        return nil, err
+    }
+    return toProtoDeadLetter(letter, true)
+}
+
+// ReplayDeadLetters ingests the stored payloads of matching dead letters
+// again. Delivered items leave the dead-letter store; failures stay with
+// their attempt count raised.
+func (s *Server) ReplayDeadLetters(ctx context.Context, req *pb.DeadLetterListRequest) (*pb.IngestionResponse, error) {
+    letters, err := s.findDeadLetters(ctx, req, 0)
+    if err != nil {
+        return nil, err
+    }
+    return s.replay(ctx, letters), nil
+}
+
+// ReplayDeadLetter replays a single dead letter
+func (s *Server) ReplayDeadLetter(ctx context.Context, req *pb.DeadLetterRequest) (*pb.IngestionResponse, error) {
+    letter, err := s.loadDeadLetter(ctx, req.GetId())
+    if err != nil {
+        return nil, err
+    }
+    return s.replay(ctx, []models.DeadLetter{*letter}), nil
+}
+
+// DiscardDeadLetter drops a dead letter without delivering it
+func (s *Server) DiscardDeadLetter(ctx context.Context, req *pb.DeadLetterRequest) (*emptypb.Empty, error) {
+    if req.GetId() == 0 {
+        return nil, status.Error(codes.InvalidArgument, "id is required")
+    }
+
+    result := s.db.WithContext(ctx).Delete(&models.DeadLetter{}, req.GetId())
+    if result.Error != nil {
+        return nil, status.Errorf(codes.Internal, "failed to discard dead letter: %v", result.Error)
+    }
+    if result.RowsAffected == 0 {
+        return nil, status.Errorf(codes.NotFound, "dead letter %d not found", req.GetId())
+    }
+    log.Printf("Discarded dead letter %d", req.GetId())
+    return &emptypb.Empty{}, nil
+}
+
+// replay sends dead-lettered items through the ingestion pipeline
+func (s *Server) replay(ctx context.Context, letters []models.DeadLetter) *pb.IngestionResponse {
+    result := newIngestionResult()
+    pipeline := s.newRequestPipeline(ctx, result.record)
+    defer pipeline.close()
+
+    for i := range letters {
+        pipeline.add(letters[i].ToItem())
+    }
+    return s.finishIngestion(pipeline, result)
+}
+
+// findDeadLetters loads up to the request's limit of matching dead letters,
+// oldest failure first
+func (s *Server) findDeadLetters(ctx context.Context, req *pb.DeadLetterListRequest, offset int) ([]models.DeadLetter, error) {
+    var letters []models.DeadLetter
+    err := s.deadLetterQuery(ctx, req).
+        Order("first_failed_at, id").
+        Limit(s.deadLetterLimit(int(req.GetLimit()))).
+        Offset(offset).
+        Find(&letters).Error
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to list dead letters: %v", err)
+    }
+    return letters, nil
+}
+
+func (s *Server) loadDeadLetter(ctx context.Context, id uint64) (*models.DeadLetter, error) {
+    if id == 0 {
+        return nil, status.Error(codes.InvalidArgument, "id is required")
+    }
+
+    var letter models.DeadLetter
+    err := s.db.WithContext(ctx).First(&letter, id).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, status.Errorf(codes.NotFound, "dead letter %d not found", id)
+    }
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to load dead letter: %v", err)
+    }
+    return &letter, nil
+}
+
+// deadLetterQuery selects dead letters matching a request; empty fields
+// match everything
+func (s *Server) deadLetterQuery(ctx context.Context, req *pb.DeadLetterListRequest) *gorm.DB {
+    query := s.db.WithContext(ctx).Model(&models.DeadLetter{})
+    if req.GetDataType() != "" {
+        query = query.Where("data_type = ?", req.GetDataType())
+    }
+    if req.GetJobId() != "" {
+        query = query.Where("job_id = ?", req.GetJobId())
+    }
+    if len(req.GetItemIds()) > 0 {
+        query = query.Where("item_id IN ?", req.GetItemIds())
+    }
+    return query
+}
+
+// deadLetterLimit defaults and caps a request's limit at MaxRecordsPerQuery
+func (s *Server) deadLetterLimit(limit int) int {
+    if limit <= 0 || limit > s.config.MaxRecordsPerQuery {
+        return s.config.MaxRecordsPerQuery
+    }
+    return limit
+}
+
+// toProtoDeadLetter converts a dead letter, optionally with the payload and
+// metadata it is replayed with
+func toProtoDeadLetter(letter *models.DeadLetter, withPayload bool) (*pb.DeadLetter, error) {
+    resp := &pb.DeadLetter{
+        Id:                    uint64(letter.ID),
+        ItemId:                letter.ItemID,
+        DataType:              letter.DataType,
+        JobId:                 letter.JobID,
+        ClassificationMarking: letter.ClassificationMarking,
+        LastError:             letter.LastError,
+        Attempts:              int32(letter.Attempts),
+        FirstFailedAt:         timestamppb.New(letter.FirstFailedAt),
+        LastFailedAt:          timestamppb.New(letter.LastFailedAt),
+    }
+    if !withPayload {
+        return resp, nil
+    }
+
+    var err error
+    if resp.Payload, err = jsonStruct(letter.Payload); err != nil {
+        return nil, status.Errorf(codes.Internal, "invalid payload in dead letter %d: %v", letter.ID, err)
+    }
+    if resp.Metadata, err = jsonStruct(letter.Metadata); err != nil {
+        return nil, status.Errorf(codes.Internal, "invalid metadata in dead letter %d: %v", letter.ID, err)
+    }
+    return resp, nil
+}
+
+// jsonStruct decodes a stored JSON object; empty values decode to nil
+func jsonStruct(raw datatypes.JSON) (*structpb.Struct, error) {
+    if len(raw) == 0 {
+        return nil, nil
+    }
+    value := &structpb.Struct{}
+    if err := value.UnmarshalJSON(raw); err != nil {
+        return nil, err
+    }
+    return value, nil
+}
+
+// recordDeadLetters stores items that failed to be prepared or delivered.
+// Writes outlive ctx so items from timed out or cancelled operations are
+// kept too.
+func (s *Server) recordDeadLetters(ctx context.Context, items []*models.BLADEItem, errs []error) {
+    db := s.db.WithContext(context.WithoutCancel(ctx))
+    now := time.Now()
+
+    // A soft-deleted row means the item was delivered or discarded since it
+    // last failed, so its history starts over
+    restart := "CASE WHEN dead_letters.deleted_at IS NULL THEN %s ELSE %s END"
+    updates := append(
+        clause.AssignmentColumns([]string{
+            "data_type", "job_id", "data_source_id", "classification_marking", "last_modified",
+            "payload", "metadata", "last_error", "last_failed_at", "updated_at",
+        }),
+        clause.Assignments(map[string]interface{}{
+            "attempts":        gorm.Expr(fmt.Sprintf(restart, "dead_letters.attempts + 1", "1")),
+            "first_failed_at": gorm.Expr(fmt.Sprintf(restart, "dead_letters.first_failed_at", "excluded.first_failed_at")),
+            "created_at":      gorm.Expr(fmt.Sprintf(restart, "dead_letters.created_at", "excluded.created_at")),
+            "deleted_at":      nil,
+        })...,
+    )
+
+    for i, item := range items {
+        if errs[i] == nil {
+            continue
+        }
+        letter := &models.DeadLetter{
+            ItemID:                item.ItemID,
+            DataType:              item.DataType,
+            JobID:                 item.IngestionJobID,
+            DataSourceID:          item.DataSourceID,
+            ClassificationMarking: item.ClassificationMarking,
+            LastModified:          item.LastModified,
+            Payload:               item.Data,
+            Metadata:              item.Metadata,
+            LastError:             errs[i].Error(),
+            Attempts:              1,
+            FirstFailedAt:         now,
+            LastFailedAt:          now,
+        }
+        err := db.Clauses(clause.OnConflict{
+            Columns:   []clause.Column{{Name: "item_id"}},
+            DoUpdates: updates,
+        }).Create(letter).Error
+        if err != nil {
+            log.Printf("Failed to dead-letter %s: %v", item.ItemID, err)
+        }
+    }
+}
+
+// clearDeadLetters removes the dead letters of delivered items
+func (s *Server) clearDeadLetters(ctx context.Context, itemIDs []string) {
+    if len(itemIDs) == 0 {
+        return
+    }
+    err := s.db.WithContext(context.WithoutCancel(ctx)).
+        Where("item_id IN ?", itemIDs).
+        Delete(&models.DeadLetter{}).Error
+    if err != nil {
+        log.Printf("Failed to clear dead letters: %v", err)
+    }
+}
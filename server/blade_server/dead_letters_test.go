@@ -0,0 +1,144 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+// deadLetterColumns are the dead_letters columns returned by sqlmock
+var deadLetterColumns = []string{
+    "id", "item_id", "data_type", "job_id", "classification_marking",
+    "payload", "metadata", "last_error", "attempts", "first_failed_at", "last_failed_at",
+}
+
+// newGateway serves s through the REST gateway
+func newGateway(t *testing.T, s *Server) http.Handler {
+    mux := runtime.NewServeMux()
+    require.NoError(t, pb.RegisterBLADEIngestionServiceHandlerServer(context.Background(), mux, s))
+    return mux
+}
+
+func TestListDeadLettersThroughGateway(t *testing.T) {
+    s, mock := newMockServer(t, &utils.Config{MaxRecordsPerQuery: 100})
+    failed := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+    mock.ExpectQuery(`SELECT count\(\*\) FROM "dead_letters" WHERE data_type = \$1 AND item_id IN \(\$2,\$3\)`).
+        WithArgs("maintenance", "M-1", "M-2").
+        WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+    mock.ExpectQuery(`SELECT \* FROM "dead_letters" WHERE data_type = \$1 AND item_id IN \(\$2,\$3\) AND "dead_letters"."deleted_at" IS NULL ORDER BY first_failed_at, id LIMIT \$4 OFFSET \$5`).
+        WithArgs("maintenance", "M-1", "M-2", 25, 5).
+        WillReturnRows(sqlmock.NewRows(deadLetterColumns).
+            AddRow(3, "M-1", "maintenance", "job-1", "UNCLASSIFIED", `{"a":1}`, nil, "catalog sink: rejected", 2, failed, failed))
+
+    // The path would also match /blade/{dataType}
+    rec := httptest.NewRecorder()
+    newGateway(t, s).ServeHTTP(rec, httptest.NewRequest("GET", "/blade/dead-letters?dataType=maintenance&itemIds=M-1&itemIds=M-2&limit=25&offset=5", nil))
+    require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+    assert.NoError(t, mock.ExpectationsWereMet())
+
+    var list struct {
+        DeadLetters []map[string]interface{} `json:"deadLetters"`
+        TotalCount  string                   `json:"totalCount"`
+    }
+    require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+    assert.Equal(t, "7", list.TotalCount)
+    require.Len(t, list.DeadLetters, 1)
+    assert.Equal(t, "M-1", list.DeadLetters[0]["itemId"])
+    assert.Equal(t, float64(2), list.DeadLetters[0]["attempts"])
+    assert.Nil(t, list.DeadLetters[0]["payload"], "lists leave payloads out")
+}
+
+func TestGetDeadLetterIncludesPayload(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+    failed := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+    mock.ExpectQuery(`SELECT \* FROM "dead_letters" WHERE "dead_letters"."id" = \$1`).
+        WithArgs(3, 1).
+        WillReturnRows(sqlmock.NewRows(deadLetterColumns).
+            AddRow(3, "M-1", "maintenance", "job-1", "UNCLASSIFIED", `{"priority":"HIGH"}`, `{"source":"replay"}`, "rejected", 1, failed, failed))
+
+    letter, err := s.GetDeadLetter(context.Background(), &pb.DeadLetterRequest{Id: 3})
+    require.NoError(t, err)
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, "HIGH", letter.GetPayload().AsMap()["priority"])
+    assert.Equal(t, "replay", letter.GetMetadata().AsMap()["source"])
+    assert.Equal(t, failed, letter.GetFirstFailedAt().AsTime())
+}
+
+func TestDeadLetterNotFound(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+
+    mock.ExpectQuery(`SELECT \* FROM "dead_letters"`).WillReturnRows(sqlmock.NewRows(deadLetterColumns))
+    _, err := s.ReplayDeadLetter(context.Background(), &pb.DeadLetterRequest{Id: 9})
+    assert.Equal(t, codes.NotFound, status.Code(err))
+
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE "dead_letters" SET "deleted_at"=\$1 WHERE "dead_letters"."id" = \$2`).
+        WithArgs(sqlmock.AnyArg(), 9).
+        WillReturnResult(sqlmock.NewResult(0, 0))
+    mock.ExpectCommit()
+    _, err = s.DiscardDeadLetter(context.Background(), &pb.DeadLetterRequest{Id: 9})
+    assert.Equal(t, codes.NotFound, status.Code(err))
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeadLetterGatewayRejectsInvalidRequests(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+    gateway := newGateway(t, s)
+
+    requests := []*http.Request{
+        httptest.NewRequest("GET", "/blade/dead-letters/abc", nil),
+        httptest.NewRequest("DELETE", "/blade/dead-letters/0", nil),
+        httptest.NewRequest("POST", "/blade/dead-letters/replay", strings.NewReader(`{"limit": "many"}`)),
+        httptest.NewRequest("GET", "/blade/dead-letters?limit=x", nil),
+        httptest.NewRequest("GET", "/blade/dead-letters?offset=-1", nil),
+    }
+    for _, req := range requests {
+        rec := httptest.NewRecorder()
+        gateway.ServeHTTP(rec, req)
+        assert.Equal(t, http.StatusBadRequest, rec.Code, "%s %s", req.Method, req.URL)
+        assert.Contains(t, rec.Body.String(), `"message"`)
+    }
+
+    // Nothing reached the database
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPipelineDeadLettersItemsThatFailPreparation(t *testing.T) {
+    s, rec := newRecordingServer(t, &utils.Config{EnableDataValidation: true, CatalogBatchSize: 2, ConcurrentUploads: 1})
+
+    result := newIngestionResult()
+    p := s.newIngestPipeline(context.Background(), result.record)
+    p.sinks[0] = []Sink{&fakeSink{name: "fake"}}
+    p.add(&models.BLADEItem{
+        ItemID:                "M-1",
+        DataType:              "maintenance",
+        ClassificationMarking: "BOGUS",
+        IngestionJobID:        "job-7",
+        Data:                  []byte(`{}`),
+    })
+    p.close()
+
+    assert.Equal(t, int32(1), result.failed)
+    inserts := rec.matching(`INSERT INTO "dead_letters"`)
+    require.Len(t, inserts, 1)
+    assert.Contains(t, inserts[0].Args, "M-1")
+    assert.Contains(t, inserts[0].Args, "job-7")
+    assert.Contains(t, inserts[0].Args, "invalid classification marking: BOGUS")
+}
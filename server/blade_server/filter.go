@@ -0,0 +1,691 @@
+package blade_server
+
+import (
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+
+    "blade-ingestion-service/database/models"
+)
+
+// Filter expressions accepted by QueryBLADE, BulkIngestBLADE and sync jobs.
+//
+//     expr       := and ( OR and )*
+//     and        := term ( AND term )*
+//     term       := '(' expr ')' | predicate
+//     predicate  := column op value
+//                 | column [NOT] IN '(' value ( ',' value )* ')'
+//                 | column [NOT] BETWEEN value AND value
+//                 | column [NOT] LIKE string
+//                 | column IS [NOT] NULL
+//     op         := '=' | '!=' | '<>' | '<' | '<=' | '>' | '>='
+//     value      := string | number | TRUE | FALSE
+//
+// Strings are single quoted with '' as the escape for a quote. Keywords are
+// case-insensitive. Columns are checked against the data type's schema and
+// every value is compiled to a bound statement parameter.
+
+const (
+    maxFilterLength = 4096
+    maxFilterTerms  = 64
+    maxFilterDepth  = 16
+)
+
+// FilterError describes an invalid filter expression
+type FilterError struct {
+    Pos int
+    Msg string
+}
+
+func (e *FilterError) Error() string {
+    return fmt.Sprintf("invalid filter at position %d: %s", e.Pos, e.Msg)
+}
+
+// ============= AST =============
+
+// FilterExpr is a node in a parsed filter expression
+type FilterExpr interface {
+    compile(c *filterCompiler) (string, error)
+}
+
+// LogicalExpr joins two expressions with AND or OR
+type LogicalExpr struct {
+    Op    string
+    Left  FilterExpr
+    Right FilterExpr
+}
+
+// ComparisonExpr compares a column with a value
+type ComparisonExpr struct {
+    Column string
+    Op     string
+    Value  interface{}
+    Pos    int
+}
+
+// InExpr tests a column for membership in a list of values
+type InExpr struct {
+    Column  string
+    Negated bool
+    Values  []interface{}
+    Pos     int
+}
+
+// BetweenExpr tests a column against an inclusive range
+type BetweenExpr struct {
+    Column  string
+    Negated bool
+    Low     interface{}
+    High    interface{}
+    Pos     int
+}
+
+// LikeExpr matches a string column against a pattern
+type LikeExpr struct {
+    Column  string
+    Negated bool
+    Pattern string
+    Pos     int
+}
+
+// NullExpr tests a column for NULL
+type NullExpr struct {
+    Column  string
+    Negated bool
+    Pos     int
+}
+
+// ============= Lexer =============
+
+type tokenKind int
+
+const (
+    tokEOF tokenKind = iota
+    tokIdent
+    tokString
+    tokNumber
+    tokOperator
+    tokLParen
+    tokRParen
+    tokComma
+)
+
+type token struct {
+    kind tokenKind
+    text string
+    pos  int
+}
+
+// keyword reports whether the token is the given case-insensitive keyword
+func (t token) keyword(kw string) bool {
+    return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func tokenize(input string) ([]token, error) {
+    var tokens []token
+    runes := []rune(input)
+
+    for i := 0; i < len(runes); {
+        r := runes[i]
+        switch {
+        case unicode.IsSpace(r):
+            i++
+
+        case r == '(':
+            tokens = append(tokens, token{tokLParen, "(", i})
+            i++
+        case r == ')':
+            tokens = append(tokens, token{tokRParen, ")", i})
+            i++
+        case r == ',':
+            tokens = append(tokens, token{tokComma, ",", i})
+            i++
+
+        case r == '\'':
+            start := i
+            var sb strings.Builder
+            i++
+            for {
+                if i >= len(runes) {
+                    return nil, &FilterError{start, "unterminated string"}
+                }
+                if runes[i] == '\'' {
+                    if i+1 < len(runes) && runes[i+1] == '\'' {
+                        sb.WriteRune('\'')
+                        i += 2
+                        continue
+                    }
+                    i++
+                    break
+                }
+                sb.WriteRune(runes[i])
+                i++
+            }
+            tokens = append(tokens, token{tokString, sb.String(), start})
+
+        case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+            start := i
+            i++
+            for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+                i++
+            }
+            tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+
+        case unicode.IsLetter(r) || r == '_':
+            start := i
+            for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+                i++
+            }
+            tokens = append(tokens, token{tokIdent, string(runes[start:i]), start})
+
+        case strings.ContainsRune("=!<>", r):
+            start := i
+            op := string(r)
+            if i+1 < len(runes) {
+                two := op + string(runes[i+1])
+                if two == "!=" || two == "<>" || two == "<=" || two == ">=" {
+                    op = two
+                }
+            }
+            if op == "!" {
+                return nil, &FilterError{start, "unexpected '!'"}
+            }
+            i += len(op)
+            tokens = append(tokens, token{tokOperator, op, start})
+
+        default:
+            return nil, &FilterError{i, fmt.Sprintf("unexpected character %q", r)}
+        }
+    }
+
+    tokens = append(tokens, token{tokEOF, "", len(runes)})
+    return tokens, nil
+}
+
+// ============= Parser =============
+
+type filterParser struct {
+    tokens []token
+    pos    int
+    terms  int
+    depth  int
+}
+
+// ParseFilter parses a filter expression into an AST
+func ParseFilter(input string) (FilterExpr, error) {
+    if len(input) > maxFilterLength {
+        return nil, &FilterError{maxFilterLength, fmt.Sprintf("filter exceeds %d characters", maxFilterLength)}
+    }
+
+    tokens, err := tokenize(input)
+    if err != nil {
+        return nil, err
+    }
+
+    p := &filterParser{tokens: tokens}
+    expr, err := p.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    if tok := p.peek(); tok.kind != tokEOF {
+        return nil, &FilterError{tok.pos, fmt.Sprintf("unexpected %q", tok.text)}
+    }
+    return expr, nil
+}
+
+func (p *filterParser) peek() token {
+    return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+    tok := p.tokens[p.pos]
+    if tok.kind != tokEOF {
+        p.pos++
+    }
+    return tok
+}
+
+func (p *filterParser) expectKeyword(kw string) error {
+    tok := p.next()
+    if !tok.keyword(kw) {
+        return &FilterError{tok.pos, fmt.Sprintf("expected %s", kw)}
+    }
+    return nil
+}
+
+func (p *filterParser) parseOr() (FilterExpr, error) {
+    left, err := p.parseAnd()
+    if err != nil {
+        return nil, err
+    }
+    for p.peek().keyword("OR") {
+        p.next()
+        right, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        left = &LogicalExpr{Op: "OR", Left: left, Right: right}
+    }
+    return left, nil
+}
+
+func (p *filterParser) parseAnd() (FilterExpr, error) {
+    left, err := p.parseTerm()
+    if err != nil {
+        return nil, err
+    }
+    for p.peek().keyword("AND") {
+        p.next()
+        right, err := p.parseTerm()
+        if err != nil {
+            return nil, err
+        }
+        left = &LogicalExpr{Op: "AND", Left: left, Right: right}
+    }
+    return left, nil
+}
+
+func (p *filterParser) parseTerm() (FilterExpr, error) {
+    tok := p.peek()
+    if tok.kind == tokLParen {
+        p.next()
+        p.depth++
+        if p.depth > maxFilterDepth {
+            return nil, &FilterError{tok.pos, fmt.Sprintf("filter nesting exceeds %d levels", maxFilterDepth)}
+        }
+        expr, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        if closing := p.next(); closing.kind != tokRParen {
+            return nil, &FilterError{closing.pos, "expected ')'"}
+        }
+        p.depth--
+        return expr, nil
+    }
+
+    p.terms++
+    if p.terms > maxFilterTerms {
+        return nil, &FilterError{tok.pos, fmt.Sprintf("filter exceeds %d conditions", maxFilterTerms)}
+    }
+    return p.parsePredicate()
+}
+
+func (p *filterParser) parsePredicate() (FilterExpr, error) {
+    colTok := p.next()
+    if colTok.kind != tokIdent || isFilterKeyword(colTok.text) {
+        return nil, &FilterError{colTok.pos, "expected column name"}
+    }
+    column := strings.ToLower(colTok.text)
+
+    if p.peek().kind == tokOperator {
+        op := p.next().text
+        if op == "<>" {
+            op = "!="
+        }
+        value, err := p.parseValue()
+        if err != nil {
+            return nil, err
+        }
+        return &ComparisonExpr{Column: column, Op: op, Value: value, Pos: colTok.pos}, nil
+    }
+
+    if p.peek().keyword("IS") {
+        p.next()
+        negated := false
+        if p.peek().keyword("NOT") {
+            p.next()
+            negated = true
+        }
+        if err := p.expectKeyword("NULL"); err != nil {
+            return nil, err
+        }
+        return &NullExpr{Column: column, Negated: negated, Pos: colTok.pos}, nil
+    }
+
+    negated := false
+    if p.peek().keyword("NOT") {
+        p.next()
+        negated = true
+    }
+
+    opTok := p.next()
+    switch {
+    case opTok.keyword("IN"):
+        if open := p.next(); open.kind != tokLParen {
+            return nil, &FilterError{open.pos, "expected '(' after IN"}
+        }
+        var values []interface{}
+        for {
+            value, err := p.parseValue()
+            if err != nil {
+                return nil, err
+            }
+            values = append(values, value)
+            p.terms++
+            if p.terms > maxFilterTerms {
+                return nil, &FilterError{opTok.pos, fmt.Sprintf("filter exceeds %d conditions", maxFilterTerms)}
+            }
+
+            sep := p.next()
+            if sep.kind == tokRParen {
+                break
+            }
+            if sep.kind != tokComma {
+                return nil, &FilterError{sep.pos, "expected ',' or ')' in IN list"}
+            }
+        }
+        return &InExpr{Column: column, Negated: negated, Values: values, Pos: colTok.pos}, nil
+
+    case opTok.keyword("BETWEEN"):
+        low, err := p.parseValue()
+        if err != nil {
+            return nil, err
+        }
+        if err := p.expectKeyword("AND"); err != nil {
+            return nil, err
+        }
+        high, err := p.parseValue()
+        if err != nil {
+            return nil, err
+        }
+        return &BetweenExpr{Column: column, Negated: negated, Low: low, High: high, Pos: colTok.pos}, nil
+
+    case opTok.keyword("LIKE"):
+        patTok := p.next()
+        if patTok.kind != tokString {
+            return nil, &FilterError{patTok.pos, "LIKE requires a string pattern"}
+        }
+        return &LikeExpr{Column: column, Negated: negated, Pattern: patTok.text, Pos: colTok.pos}, nil
+    }
+
+    return nil, &FilterError{opTok.pos, fmt.Sprintf("expected operator after column %q", column)}
+}
+
+func (p *filterParser) parseValue() (interface{}, error) {
+    tok := p.next()
+    switch {
+    case tok.kind == tokString:
+        return tok.text, nil
+    case tok.kind == tokNumber:
+        if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+            return n, nil
+        }
+        f, err := strconv.ParseFloat(tok.text, 64)
+        if err != nil {
+            return nil, &FilterError{tok.pos, fmt.Sprintf("invalid number %q", tok.text)}
+        }
+        return f, nil
+    case tok.keyword("TRUE"):
+        return true, nil
+    case tok.keyword("FALSE"):
+        return false, nil
+    }
+    return nil, &FilterError{tok.pos, "expected a string, number or boolean value"}
+}
+
+func isFilterKeyword(word string) bool {
+    switch strings.ToUpper(word) {
+    case "AND", "OR", "NOT", "IN", "BETWEEN", "LIKE", "IS", "NULL", "TRUE", "FALSE":
+        return true
+    }
+    return false
+}
+
+// ============= Schema =============
+
+// columnKind is the value category a column accepts
+type columnKind int
+
+const (
+    kindString columnKind = iota
+    kindNumber
+    kindBool
+    kindTime
+)
+
+func (k columnKind) String() string {
+    switch k {
+    case kindNumber:
+        return "number"
+    case kindBool:
+        return "boolean"
+    case kindTime:
+        return "timestamp"
+    default:
+        return "string"
+    }
+}
+
+// commonFilterColumns are available for every data type
+var commonFilterColumns = map[string]columnKind{
+    "classification": kindString,
+}
+
+// FilterColumns returns the filterable columns of a data type, derived from
+// the JSON tags of its typed model
+func FilterColumns(dataType string) map[string]columnKind {
+    record := models.NewBLADERecord(models.BLADEItemType(dataType))
+    if record == nil {
+        return nil
+    }
+
+    columns := make(map[string]columnKind)
+    for name, kind := range commonFilterColumns {
+        columns[name] = kind
+    }
+
+    t := reflect.TypeOf(record).Elem()
+    for i := 0; i < t.NumField(); i++ {
+        field := t.Field(i)
+        name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+        if name == "" || name == "-" {
+            continue
+        }
+
+        ft := field.Type
+        if ft.Kind() == reflect.Ptr {
+            ft = ft.Elem()
+        }
+        switch {
+        case ft == reflect.TypeOf(time.Time{}):
+            columns[name] = kindTime
+        case ft.Kind() == reflect.Bool:
+            columns[name] = kindBool
+        case ft.Kind() >= reflect.Int && ft.Kind() <= reflect.Float64:
+            columns[name] = kindNumber
+        default:
+            columns[name] = kindString
+        }
+    }
+
+    return columns
+}
+
+// ============= Compiler =============
+
+type filterCompiler struct {
+    columns map[string]columnKind
+    params  []StatementParameter
+}
+
+// CompileFilter parses a filter and compiles it to a parameterized WHERE
+// fragment for the given data type. An empty filter compiles to "".
+func CompileFilter(dataType, input string) (string, []StatementParameter, error) {
+    if strings.TrimSpace(input) == "" {
+        return "", nil, nil
+    }
+
+    columns := FilterColumns(dataType)
+    if columns == nil {
+        return "", nil, fmt.Errorf("unknown data type %q", dataType)
+    }
+
+    expr, err := ParseFilter(input)
+    if err != nil {
+        return "", nil, err
+    }
+
+    c := &filterCompiler{columns: columns}
+    sql, err := expr.compile(c)
+    if err != nil {
+        return "", nil, err
+    }
+    return sql, c.params, nil
+}
+
+// column validates a column reference and returns its kind
+func (c *filterCompiler) column(name string, pos int) (columnKind, error) {
+    kind, ok := c.columns[name]
+    if !ok {
+        return 0, &FilterError{pos, fmt.Sprintf("unknown column %q", name)}
+    }
+    return kind, nil
+}
+
+// bind checks a value against the column kind and returns its marker
+func (c *filterCompiler) bind(column string, kind columnKind, value interface{}, pos int) (string, error) {
+    switch kind {
+    case kindString:
+        if _, ok := value.(string); !ok {
+            return "", &FilterError{pos, fmt.Sprintf("column %q expects a string", column)}
+        }
+    case kindNumber:
+        switch value.(type) {
+        case int64, float64:
+        default:
+            return "", &FilterError{pos, fmt.Sprintf("column %q expects a number", column)}
+        }
+    case kindBool:
+        if _, ok := value.(bool); !ok {
+            return "", &FilterError{pos, fmt.Sprintf("column %q expects a boolean", column)}
+        }
+    case kindTime:
+        str, ok := value.(string)
+        if !ok {
+            return "", &FilterError{pos, fmt.Sprintf("column %q expects a timestamp string", column)}
+        }
+        ts, err := parseFilterTime(str)
+        if err != nil {
+            return "", &FilterError{pos, fmt.Sprintf("column %q: %v", column, err)}
+        }
+        value = ts
+    }
+
+    name := fmt.Sprintf("f%d", len(c.params))
+    c.params = append(c.params, NewParameter(name, value))
+    return ":" + name, nil
+}
+
+// parseFilterTime accepts RFC 3339 timestamps or plain dates
+func parseFilterTime(value string) (time.Time, error) {
+    if ts, err := time.Parse(time.RFC3339, value); err == nil {
+        return ts, nil
+    }
+    if ts, err := time.Parse("2006-01-02", value); err == nil {
+        return ts, nil
+    }
+    return time.Time{}, fmt.Errorf("invalid timestamp %q, expected RFC 3339 or YYYY-MM-DD", value)
+}
+
+func (e *LogicalExpr) compile(c *filterCompiler) (string, error) {
+    left, err := e.Left.compile(c)
+    if err != nil {
+        return "", err
+    }
+    right, err := e.Right.compile(c)
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("(%s %s %s)", left, e.Op, right), nil
+}
+
+func (e *ComparisonExpr) compile(c *filterCompiler) (string, error) {
+    kind, err := c.column(e.Column, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    if kind == kindBool && e.Op != "=" && e.Op != "!=" {
+        return "", &FilterError{e.Pos, fmt.Sprintf("operator %s is not valid for boolean column %q", e.Op, e.Column)}
+    }
+    marker, err := c.bind(e.Column, kind, e.Value, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("%s %s %s", e.Column, e.Op, marker), nil
+}
+
+func (e *InExpr) compile(c *filterCompiler) (string, error) {
+    kind, err := c.column(e.Column, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    markers := make([]string, 0, len(e.Values))
+    for _, v := range e.Values {
+        marker, err := c.bind(e.Column, kind, v, e.Pos)
+        if err != nil {
+            return "", err
+        }
+        markers = append(markers, marker)
+    }
+    op := "IN"
+    if e.Negated {
+        op = "NOT IN"
+    }
+    return fmt.Sprintf("%s %s (%s)", e.Column, op, strings.Join(markers, ", ")), nil
+}
+
+func (e *BetweenExpr) compile(c *filterCompiler) (string, error) {
+    kind, err := c.column(e.Column, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    if kind == kindBool {
+        return "", &FilterError{e.Pos, fmt.Sprintf("BETWEEN is not valid for boolean column %q", e.Column)}
+    }
+    low, err := c.bind(e.Column, kind, e.Low, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    high, err := c.bind(e.Column, kind, e.High, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    op := "BETWEEN"
+    if e.Negated {
+        op = "NOT BETWEEN"
+    }
+    return fmt.Sprintf("%s %s %s AND %s", e.Column, op, low, high), nil
+}
+
+func (e *LikeExpr) compile(c *filterCompiler) (string, error) {
+    kind, err := c.column(e.Column, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    if kind != kindString {
+        return "", &FilterError{e.Pos, fmt.Sprintf("LIKE requires a string column, %q is a %s", e.Column, kind)}
+    }
+    marker, err := c.bind(e.Column, kind, e.Pattern, e.Pos)
+    if err != nil {
+        return "", err
+    }
+    op := "LIKE"
+    if e.Negated {
+        op = "NOT LIKE"
+    }
+    return fmt.Sprintf("%s %s %s", e.Column, op, marker), nil
+}
+
+func (e *NullExpr) compile(c *filterCompiler) (string, error) {
+    if _, err := c.column(e.Column, e.Pos); err != nil {
+        return "", err
+    }
+    if e.Negated {
+        return e.Column + " IS NOT NULL", nil
+    }
+    return e.Column + " IS NULL", nil
+}
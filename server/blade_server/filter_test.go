@@ -0,0 +1,56 @@
+package blade_server
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestCompileFilter(t *testing.T) {
+    sql, params, err := CompileFilter("maintenance",
+        "priority IN ('HIGH', 'CRITICAL') AND (base_location LIKE 'Ramstein%' OR aircraft_type = 'F-16')")
+
+    assert.NoError(t, err)
+    assert.Equal(t, "(priority IN (:f0, :f1) AND (base_location LIKE :f2 OR aircraft_type = :f3))", sql)
+    assert.Len(t, params, 4)
+    assert.Equal(t, "HIGH", *params[0].Value)
+    assert.Equal(t, "Ramstein%", *params[2].Value)
+}
+
+func TestCompileFilterTypes(t *testing.T) {
+    sql, params, err := CompileFilter("logistics", "quantity BETWEEN 10 AND 100 and shipped_date >= '2024-01-01'")
+    assert.NoError(t, err)
+    assert.Equal(t, "(quantity BETWEEN :f0 AND :f1 AND shipped_date >= :f2)", sql)
+    assert.Equal(t, "BIGINT", params[0].Type)
+    assert.Equal(t, "TIMESTAMP", params[2].Type)
+
+    sql, params, err = CompileFilter("sortie", "actual_arrival IS NOT NULL")
+    assert.NoError(t, err)
+    assert.Equal(t, "actual_arrival IS NOT NULL", sql)
+    assert.Empty(t, params)
+
+    sql, _, err = CompileFilter("sortie", "   ")
+    assert.NoError(t, err)
+    assert.Empty(t, sql)
+}
+
+func TestCompileFilterRejectsInvalidInput(t *testing.T) {
+    cases := map[string]string{
+        "priority = 'HIGH'; DROP TABLE x":     "unexpected character",
+        "priority = 'HIGH' OR 1=1":            "expected column name",
+        "unknown_col = 'x'":                   "unknown column",
+        "quantity = 'ten'":                    "unknown column",
+        "priority = 5":                        "expects a string",
+        "priority = 'HIGH":                    "unterminated string",
+        "priority LIKE 5":                     "string pattern",
+        "estimated_completion = 'yesterday'":  "invalid timestamp",
+        "priority = 'HIGH' -- comment":        "unexpected character",
+    }
+
+    for filter, want := range cases {
+        _, _, err := CompileFilter("maintenance", filter)
+        if assert.Error(t, err, filter) {
+            assert.Contains(t, err.Error(), want, filter)
+        }
+    }
+}
@@ -2,33 +2,174 @@ package blade_server
 
 import (
     "bytes"
+    "context"
     "encoding/json"
+    "errors"
     "fmt"
-    "io"
     "mime/multipart"
     "net/http"
+    "net/url"
+    "sync/atomic"
     
     "blade-ingestion-service/database/models"
+    "blade-ingestion-service/server/utils"
 )
 
+// ErrBatchUnsupported is returned when the catalog has no batch upload endpoint
+var ErrBatchUnsupported = errors.New("catalog does not support batch uploads")
+
 // CatalogUploader handles uploading items to the catalog
 type CatalogUploader struct {
     catalogURL string
     authToken  string
     httpClient *http.Client
+    
+    retry      *retryPolicy
+    
+    // Send BuildMetadata output instead of the item's stored metadata
+    enrichMetadata bool
+    
+    // Set once the catalog rejects a batch request as unsupported
+    batchUnsupported atomic.Bool
+}
+
+// batchManifestEntry describes one file part of a batch upload
+type batchManifestEntry struct {
+    ItemID                string          `json:"itemId"`
+    DataSource            string          `json:"dataSource"`
+    ClassificationMarking string          `json:"classificationMarking"`
+    FileName              string          `json:"fileName"`
+    Metadata              json.RawMessage `json:"metadata,omitempty"`
+}
+
+// catalogItemResponse is the catalog's reply to a single item upload. Catalog
+// versions differ in how they name the ID, so all known spellings are read.
+type catalogItemResponse struct {
+    ID          string `json:"id"`
+    CatalogID   string `json:"catalogId"`
+    SnakeCaseID string `json:"catalog_id"`
+}
+
+// catalogID returns the first ID present in the response
+func (r catalogItemResponse) catalogID() string {
+    for _, id := range []string{r.CatalogID, r.SnakeCaseID, r.ID} {
+        if id != "" {
+            return id
+        }
+    }
+    return ""
 }
 
-// NewCatalogUploader creates a new catalog uploader
-func NewCatalogUploader(catalogURL, authToken string) *CatalogUploader {
+// batchUploadResponse is the catalog's per-item outcome of a batch upload
+type batchUploadResponse struct {
+    Results []struct {
+        catalogItemResponse
+        ItemID  string `json:"itemId"`
+        Success bool   `json:"success"`
+        Error   string `json:"error,omitempty"`
+    } `json:"results"`
+}
+
+// NewCatalogUploader creates a new catalog uploader. A nil config uses a
+// single attempt and no request timeout.
+func NewCatalogUploader(catalogURL, authToken string, config *utils.CatalogUploadConfig) *CatalogUploader {
+    if config == nil {
+        config = &utils.CatalogUploadConfig{}
+    }
     return &CatalogUploader{
         catalogURL: catalogURL,
         authToken:  authToken,
-        httpClient: &http.Client{},
+        httpClient: &http.Client{Timeout: config.Timeout},
+        retry:      newRetryPolicy(config.MaxRetries, config.RetryDelay, config.MaxRetryDelay),
+        
+        enrichMetadata: config.MetadataEnrichment,
+    }
+}
+
+// UploadItem uploads a BLADE item to the catalog and sets its CatalogID from
+// the catalog's response
+func (cu *CatalogUploader) UploadItem(ctx context.Context, item *models.BLADEItem) error {
+    _, err := cu.uploadItem(ctx, item)
+    return err
+}
+
+// uploadItem uploads one item and returns the number of retries it took
+func (cu *CatalogUploader) uploadItem(ctx context.Context, item *models.BLADEItem) (int, error) {
+    contentType, body, err := cu.itemForm(item)
+    if err != nil {
+        return 0, err
+    }
+    
+    resp, retries, err := cu.send(ctx, "POST", "/catalog/item", contentType, body)
+    if err != nil {
+        return retries, err
+    }
+    defer resp.Body.Close()
+    
+    // The upload succeeded; a missing or unparseable body only loses the ID
+    var result catalogItemResponse
+    if err := json.NewDecoder(resp.Body).Decode(&result); err == nil {
+        item.CatalogID = result.catalogID()
+    }
+    
+    return retries, nil
+}
+
+// UpdateItem replaces the catalog's copy of a previously uploaded item. If
+// the catalog no longer has the item it is uploaded again.
+func (cu *CatalogUploader) UpdateItem(ctx context.Context, item *models.BLADEItem) error {
+    _, err := cu.updateItem(ctx, item)
+    return err
+}
+
+// updateItem updates one item and returns the number of retries it took
+func (cu *CatalogUploader) updateItem(ctx context.Context, item *models.BLADEItem) (int, error) {
+    if item.CatalogID == "" {
+        return 0, fmt.Errorf("item %s has no catalog ID", item.ItemID)
+    }
+    
+    contentType, body, err := cu.itemForm(item)
+    if err != nil {
+        return 0, err
+    }
+    
+    resp, retries, err := cu.send(ctx, "PUT", "/catalog/item/"+url.PathEscape(item.CatalogID), contentType, body)
+    if err != nil {
+        var statusErr *StatusError
+        if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+            item.CatalogID = ""
+            more, err := cu.uploadItem(ctx, item)
+            return retries + more, err
+        }
+        return retries, err
+    }
+    resp.Body.Close()
+    
+    return retries, nil
+}
+
+// RetractItem removes an item from the catalog. Items the catalog no longer
+// has are treated as retracted.
+func (cu *CatalogUploader) RetractItem(ctx context.Context, item *models.BLADEItem) error {
+    if item.CatalogID == "" {
+        return fmt.Errorf("item %s has no catalog ID", item.ItemID)
+    }
+    
+    resp, _, err := cu.send(ctx, "DELETE", "/catalog/item/"+url.PathEscape(item.CatalogID), "", nil)
+    if err != nil {
+        var statusErr *StatusError
+        if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+            return nil
+        }
+        return err
     }
+    resp.Body.Close()
+    
+    return nil
 }
 
-// UploadItem uploads a BLADE item to the catalog
-func (cu *CatalogUploader) UploadItem(item *models.BLADEItem) error {
+// itemForm encodes an item as the multipart form the catalog expects
+func (cu *CatalogUploader) itemForm(item *models.BLADEItem) (string, []byte, error) {
     // Create multipart writer
     body := &bytes.Buffer{}
     writer := multipart.NewWriter(body)
@@ -37,12 +178,12 @@ func (cu *CatalogUploader) UploadItem(item *models.BLADEItem) error {
     fileName := fmt.Sprintf("%s_%s.json", item.DataType, item.ItemID)
     part, err := writer.CreateFormFile("file", fileName)
     if err != nil {
-        return fmt.Errorf("failed to create form file: %w", err)
+        return "", nil, fmt.Errorf("failed to create form file: %w", err)
     }
     
     // Write JSON data
     if _, err := part.Write(item.Data); err != nil {
-        return fmt.Errorf("failed to write data: %w", err)
+        return "", nil, fmt.Errorf("failed to write data: %w", err)
     }
     
     // Add metadata fields
@@ -51,39 +192,132 @@ func (cu *CatalogUploader) UploadItem(item *models.BLADEItem) error {
     writer.WriteField("itemId", item.ItemID)
     
     // Add metadata JSON
-    if len(item.Metadata) > 0 {
-        writer.WriteField("metadata", string(item.Metadata))
+    if metadata := cu.metadataFor(item); len(metadata) > 0 {
+        writer.WriteField("metadata", string(metadata))
     }
     
     // Close writer
     if err := writer.Close(); err != nil {
-        return fmt.Errorf("failed to close writer: %w", err)
+        return "", nil, fmt.Errorf("failed to close writer: %w", err)
+    }
+    
+    return writer.FormDataContentType(), body.Bytes(), nil
+}
+
+// UploadItems uploads items in a single batch request and returns one error
+// per item along with the number of retries made. Catalogs without a batch
+// endpoint get one request per item. Uploaded items get their CatalogID set.
+func (cu *CatalogUploader) UploadItems(ctx context.Context, items []*models.BLADEItem) ([]error, int) {
+    errs := make([]error, len(items))
+    if len(items) == 0 {
+        return errs, 0
+    }
+    
+    if !cu.batchUnsupported.Load() {
+        results, retries, err := cu.UploadBatch(ctx, items)
+        if err == nil {
+            return results, retries
+        }
+        if !errors.Is(err, ErrBatchUnsupported) {
+            for i := range errs {
+                errs[i] = err
+            }
+            return errs, retries
+        }
+    }
+    
+    total := 0
+    for i, item := range items {
+        retries, err := cu.uploadItem(ctx, item)
+        errs[i] = err
+        total += retries
     }
+    return errs, total
+}
+
+// UploadBatch uploads items to the catalog's batch endpoint as one multipart
+// request and returns the per-item results and the number of retries made.
+// It returns ErrBatchUnsupported if the catalog does not expose the endpoint.
+func (cu *CatalogUploader) UploadBatch(ctx context.Context, items []*models.BLADEItem) ([]error, int, error) {
+    body := &bytes.Buffer{}
+    writer := multipart.NewWriter(body)
     
-    // Create request
-    req, err := http.NewRequest("POST", cu.catalogURL+"/catalog/item", body)
+    manifest := make([]batchManifestEntry, len(items))
+    for i, item := range items {
+        fileName := fmt.Sprintf("%s_%s.json", item.DataType, item.ItemID)
+        part, err := writer.CreateFormFile("files", fileName)
+        if err != nil {
+            return nil, 0, fmt.Errorf("failed to create form file: %w", err)
+        }
+        if _, err := part.Write(item.Data); err != nil {
+            return nil, 0, fmt.Errorf("failed to write data: %w", err)
+        }
+        
+        manifest[i] = batchManifestEntry{
+            ItemID:                item.ItemID,
+            DataSource:            fmt.Sprintf("BLADE:%s", item.DataType),
+            ClassificationMarking: item.ClassificationMarking,
+            FileName:              fileName,
+        }
+        if metadata := cu.metadataFor(item); len(metadata) > 0 {
+            manifest[i].Metadata = json.RawMessage(metadata)
+        }
+    }
+    
+    manifestJSON, err := json.Marshal(manifest)
     if err != nil {
-        return fmt.Errorf("failed to create request: %w", err)
+        return nil, 0, fmt.Errorf("failed to encode manifest: %w", err)
     }
+    writer.WriteField("items", string(manifestJSON))
     
-    // Set headers
-    req.Header.Set("Authorization", "Bearer "+cu.authToken)
-    req.Header.Set("Content-Type", writer.FormDataContentType())
+    if err := writer.Close(); err != nil {
+        return nil, 0, fmt.Errorf("failed to close writer: %w", err)
+    }
     
-    // Send request
-    resp, err := cu.httpClient.Do(req)
+    resp, retries, err := cu.send(ctx, "POST", "/catalog/items/batch", writer.FormDataContentType(), body.Bytes())
     if err != nil {
-        return fmt.Errorf("failed to send request: %w", err)
+        var statusErr *StatusError
+        if errors.As(err, &statusErr) && batchUnsupportedStatus(statusErr.StatusCode) {
+            cu.batchUnsupported.Store(true)
+            return nil, retries, ErrBatchUnsupported
+        }
+        return nil, retries, err
     }
     defer resp.Body.Close()
     
-    // Check response
-    if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-        bodyBytes, _ := io.ReadAll(resp.Body)
-        return fmt.Errorf("catalog returned status %d: %s", resp.StatusCode, string(bodyBytes))
+    var result batchUploadResponse
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return nil, retries, fmt.Errorf("failed to decode batch response: %w", err)
     }
     
-    return nil
+    outcomes := make(map[string]error, len(result.Results))
+    catalogIDs := make(map[string]string, len(result.Results))
+    for _, r := range result.Results {
+        if r.Success {
+            outcomes[r.ItemID] = nil
+            catalogIDs[r.ItemID] = r.catalogID()
+        } else {
+            outcomes[r.ItemID] = fmt.Errorf("catalog rejected item: %s", r.Error)
+        }
+    }
+    
+    errs := make([]error, len(items))
+    for i, item := range items {
+        outcome, ok := outcomes[item.ItemID]
+        if !ok {
+            outcome = fmt.Errorf("catalog returned no result for item %s", item.ItemID)
+        }
+        if outcome == nil {
+            item.CatalogID = catalogIDs[item.ItemID]
+        }
+        errs[i] = outcome
+    }
+    return errs, retries, nil
+}
+
+// batchUnsupportedStatus reports whether a status means the batch endpoint is missing
+func batchUnsupportedStatus(code int) bool {
+    return code == http.StatusNotFound || code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented
 }
 
 // CheckItemExists checks if an item already exists in the catalog
@@ -112,7 +346,20 @@ func (cu *CatalogUploader) CheckItemExists(dataType, itemID string) (bool, error
     return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 }
 
-// BuildMetadata builds metadata for catalog upload
+// metadataFor returns the metadata JSON sent to the catalog for an item
+func (cu *CatalogUploader) metadataFor(item *models.BLADEItem) []byte {
+    if !cu.enrichMetadata {
+        return item.Metadata
+    }
+    metadata, err := json.Marshal(BuildMetadata(item, "databricks"))
+    if err != nil {
+        return item.Metadata
+    }
+    return metadata
+}
+
+// BuildMetadata builds metadata for catalog upload: generic fields, then the
+// data type's enriched fields, then the item's own metadata
 func BuildMetadata(item *models.BLADEItem, source string) map[string]interface{} {
     metadata := map[string]interface{}{
         "dataType":       item.DataType,
@@ -122,6 +369,10 @@ func BuildMetadata(item *models.BLADEItem, source string) map[string]interface{}
         "importTime":     item.CreatedAt.Format("2006-01-02T15:04:05Z"),
     }
     
+    for k, v := range EnrichMetadata(item) {
+        metadata[k] = v
+    }
+    
     // Add any existing metadata
     if len(item.Metadata) > 0 {
         var existingMeta map[string]interface{}
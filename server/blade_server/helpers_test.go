@@ -0,0 +1,284 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func testItems(ids ...string) []*models.BLADEItem {
+    items := make([]*models.BLADEItem, len(ids))
+    for i, id := range ids {
+        items[i] = &models.BLADEItem{ItemID: id, DataType: "maintenance", Data: []byte(`{}`)}
+    }
+    return items
+}
+
+func TestUploadItemsBatch(t *testing.T) {
+    var batchRequests int
+    catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        assert.Equal(t, "/catalog/items/batch", r.URL.Path)
+        batchRequests++
+
+        assert.NoError(t, r.ParseMultipartForm(1<<20))
+        assert.Len(t, r.MultipartForm.File["files"], 3)
+        var manifest []batchManifestEntry
+        assert.NoError(t, json.Unmarshal([]byte(r.FormValue("items")), &manifest))
+        assert.Equal(t, "MAINT-2", manifest[1].ItemID)
+
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "results": []map[string]interface{}{
+                {"itemId": "MAINT-1", "success": true, "catalogId": "cat-1"},
+                {"itemId": "MAINT-2", "success": false, "error": "duplicate"},
+            },
+        })
+    }))
+    defer catalog.Close()
+
+    items := testItems("MAINT-1", "MAINT-2", "MAINT-3")
+    errs, _ := NewCatalogUploader(catalog.URL, "token", nil).UploadItems(context.Background(), items)
+
+    assert.Equal(t, 1, batchRequests)
+    assert.NoError(t, errs[0])
+    assert.Equal(t, "cat-1", items[0].CatalogID)
+    assert.ErrorContains(t, errs[1], "duplicate")
+    assert.ErrorContains(t, errs[2], "no result")
+}
+
+func TestUploadItemsFallsBackToSingleUploads(t *testing.T) {
+    var batchRequests, itemRequests int
+    catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/catalog/items/batch":
+            batchRequests++
+            http.NotFound(w, r)
+        case "/catalog/item":
+            itemRequests++
+            w.WriteHeader(http.StatusCreated)
+            fmt.Fprintf(w, `{"id": "cat-%d"}`, itemRequests)
+        }
+    }))
+    defer catalog.Close()
+
+    uploader := NewCatalogUploader(catalog.URL, "token", nil)
+    for i := 0; i < 2; i++ {
+        items := testItems("MAINT-1", "MAINT-2")
+        errs, _ := uploader.UploadItems(context.Background(), items)
+        for _, err := range errs {
+            assert.NoError(t, err)
+        }
+        assert.Equal(t, fmt.Sprintf("cat-%d", itemRequests), items[1].CatalogID)
+    }
+
+    // The batch endpoint is only probed once
+    assert.Equal(t, 1, batchRequests)
+    assert.Equal(t, 4, itemRequests)
+}
+
+func TestUploadItemRetries(t *testing.T) {
+    var attempts int
+    catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        attempts++
+        switch attempts {
+        case 1:
+            w.Header().Set("Retry-After", "0")
+            w.WriteHeader(http.StatusTooManyRequests)
+        case 2:
+            w.WriteHeader(http.StatusServiceUnavailable)
+        default:
+            w.WriteHeader(http.StatusCreated)
+        }
+    }))
+    defer catalog.Close()
+
+    uploader := NewCatalogUploader(catalog.URL, "token", &utils.CatalogUploadConfig{
+        MaxRetries: 3,
+        RetryDelay: time.Millisecond,
+    })
+    retries, err := uploader.uploadItem(context.Background(), testItems("MAINT-1")[0])
+    assert.NoError(t, err)
+    assert.Equal(t, 2, retries)
+
+    // Permanent errors are not retried
+    attempts = 0
+    permanent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        attempts++
+        w.WriteHeader(http.StatusBadRequest)
+    }))
+    defer permanent.Close()
+
+    uploader = NewCatalogUploader(permanent.URL, "token", &utils.CatalogUploadConfig{MaxRetries: 3})
+    retries, err = uploader.uploadItem(context.Background(), testItems("MAINT-1")[0])
+    var statusErr *StatusError
+    assert.ErrorAs(t, err, &statusErr)
+    assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+    assert.Equal(t, 0, retries)
+    assert.Equal(t, 1, attempts)
+}
+
+func TestPostRetriesOnlyUnprocessedRequests(t *testing.T) {
+    // A POST whose response was lost may have created the item
+    var posts atomic.Int32
+    dropped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        posts.Add(1)
+        conn, _, err := w.(http.Hijacker).Hijack()
+        require.NoError(t, err)
+        conn.Close()
+    }))
+    defer dropped.Close()
+
+    config := &utils.CatalogUploadConfig{MaxRetries: 3, RetryDelay: time.Millisecond}
+    _, err := NewCatalogUploader(dropped.URL, "token", config).uploadItem(context.Background(), testItems("MAINT-1")[0])
+    assert.Error(t, err)
+    assert.Equal(t, int32(1), posts.Load())
+
+    // Server errors are not retried either, unlike for an update
+    var puts atomic.Int32
+    failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method == http.MethodPut {
+            puts.Add(1)
+        } else {
+            posts.Add(1)
+        }
+        w.WriteHeader(http.StatusInternalServerError)
+    }))
+    defer failing.Close()
+
+    posts.Store(0)
+    uploader := NewCatalogUploader(failing.URL, "token", config)
+    _, err = uploader.uploadItem(context.Background(), testItems("MAINT-1")[0])
+    assert.Error(t, err)
+    assert.Equal(t, int32(1), posts.Load())
+
+    item := testItems("MAINT-1")[0]
+    item.CatalogID = "cat-1"
+    _, err = uploader.updateItem(context.Background(), item)
+    assert.Error(t, err)
+    assert.Equal(t, int32(4), puts.Load())
+
+    // A refused connection never reached the server
+    closed := httptest.NewServer(http.NotFoundHandler())
+    closed.Close()
+    retries, err := NewCatalogUploader(closed.URL, "token", config).uploadItem(context.Background(), testItems("MAINT-1")[0])
+    assert.Error(t, err)
+    assert.Equal(t, 3, retries)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+    policy := newRetryPolicy(5, 100*time.Millisecond, time.Second)
+
+    for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second} {
+        wait, ok := policy.delay(attempt, 0)
+        assert.True(t, ok)
+        assert.GreaterOrEqual(t, wait, max/2)
+        assert.LessOrEqual(t, wait, max)
+    }
+
+    wait, ok := policy.delay(0, 500*time.Millisecond)
+    assert.True(t, ok)
+    assert.Equal(t, 500*time.Millisecond, wait)
+    _, ok = policy.delay(0, time.Minute)
+    assert.False(t, ok)
+
+    assert.Equal(t, 7*time.Second, parseRetryAfter("7", time.Now()))
+    now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+}
+
+func TestUpdateAndRetractItem(t *testing.T) {
+    var requests []string
+    catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requests = append(requests, r.Method+" "+r.URL.Path)
+        switch {
+        case r.Method == "PUT" && r.URL.Path == "/catalog/item/cat-gone":
+            http.NotFound(w, r)
+        case r.Method == "POST" && r.URL.Path == "/catalog/item":
+            fmt.Fprint(w, `{"id": "cat-new"}`)
+        case r.Method == "DELETE" && r.URL.Path == "/catalog/item/cat-missing":
+            http.NotFound(w, r)
+        }
+    }))
+    defer catalog.Close()
+
+    uploader := NewCatalogUploader(catalog.URL, "token", nil)
+    item := testItems("MAINT-1")[0]
+
+    item.CatalogID = "cat-1"
+    assert.NoError(t, uploader.UpdateItem(context.Background(), item))
+    assert.Equal(t, "cat-1", item.CatalogID)
+
+    // Updating an item the catalog lost uploads it again
+    item.CatalogID = "cat-gone"
+    assert.NoError(t, uploader.UpdateItem(context.Background(), item))
+    assert.Equal(t, "cat-new", item.CatalogID)
+
+    assert.NoError(t, uploader.RetractItem(context.Background(), item))
+    item.CatalogID = "cat-missing"
+    assert.NoError(t, uploader.RetractItem(context.Background(), item))
+
+    assert.Equal(t, []string{
+        "PUT /catalog/item/cat-1",
+        "PUT /catalog/item/cat-gone",
+        "POST /catalog/item",
+        "DELETE /catalog/item/cat-new",
+        "DELETE /catalog/item/cat-missing",
+    }, requests)
+}
+
+func TestBuildMetadataEnrichesByType(t *testing.T) {
+    item := &models.BLADEItem{
+        ItemID:                "SORTIE-1",
+        DataType:              "sortie",
+        ClassificationMarking: "UNCLASSIFIED",
+        Data:                  []byte(`{"mission_id": "M-42", "mission_status": "COMPLETE", "pilot_callsign": "Viper"}`),
+        Metadata:              []byte(`{"missionStatus": "OVERRIDDEN"}`),
+    }
+
+    metadata := BuildMetadata(item, "databricks")
+    assert.Equal(t, "M-42", metadata["missionId"])
+    assert.Equal(t, "OVERRIDDEN", metadata["missionStatus"])
+    assert.NotContains(t, metadata, "pilotCallsign")
+
+    item.DataType = "logistics"
+    item.Data = []byte(`{"shipment_id": "SHIP-7", "vendor": ""}`)
+    assert.Equal(t, map[string]interface{}{"shipmentId": "SHIP-7"}, EnrichMetadata(item))
+
+    item.DataType = "maintenance"
+    item.Data = []byte(`{"aircraft_tail": "AF-123", "aircraft_type": "F-16", "base_location": "Nellis", "priority": "HIGH"}`)
+    assert.Equal(t, map[string]interface{}{
+        "aircraftTail": "AF-123",
+        "aircraftType": "F-16",
+        "baseLocation": "Nellis",
+    }, EnrichMetadata(item))
+
+    item.DataType = "deployment"
+    item.Data = []byte(`{"unit_designation": "388th FW", "deployment_location": "Kadena", "personnel_count": 120}`)
+    assert.Equal(t, map[string]interface{}{
+        "unitDesignation":    "388th FW",
+        "deploymentLocation": "Kadena",
+    }, EnrichMetadata(item))
+}
+
+func TestLiftedColumnsExistInSchemas(t *testing.T) {
+    for itemType, columns := range liftedColumns {
+        schema := FilterColumns(string(itemType))
+        require.NotNil(t, schema, itemType)
+        for _, column := range columns {
+            _, ok := schema[column]
+            _, common := commonFilterColumns[column]
+            assert.True(t, ok && !common, "%s lifts %s, which its model does not have", itemType, column)
+        }
+    }
+    assert.Len(t, metadataEnrichers, len(liftedColumns))
+}
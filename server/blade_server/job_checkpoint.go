@@ -0,0 +1,232 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "blade-ingestion-service/database/models"
+)
+
+// jobCheckpoint is the persisted position of a job. Query jobs record the
+// last result row, in item ID order, before which every row was processed;
+// sync jobs record where each data type got to.
+type jobCheckpoint struct {
+    After  *rowPosition              `json:"after,omitempty"`
+    Counts *itemCounts               `json:"counts,omitempty"`
+    Types  map[string]typeCheckpoint `json:"types,omitempty"`
+}
+
+// typeCheckpoint is the position of one data type of a sync job
+type typeCheckpoint struct {
+    // After is the last row before which every row was processed, and
+    // Counts the outcomes of those rows
+    After  *rowPosition `json:"after,omitempty"`
+    Counts itemCounts   `json:"counts"`
+    Total  int32        `json:"total"`
+
+    // Finished types are not synced again on resume
+    Done    bool  `json:"done,omitempty"`
+    Failed  bool  `json:"failed,omitempty"`
+    Deleted int32 `json:"deleted,omitempty"`
+
+    // Holds back an incremental sync's watermark
+    UnreadRows bool `json:"unreadRows,omitempty"`
+}
+
+// itemCounts tallies the outcomes of processed rows. Unchanged items are
+// skipped; a sync splits its succeeded items into added and updated ones.
+type itemCounts struct {
+    Processed int32 `json:"processed"`
+    Succeeded int32 `json:"succeeded"`
+    Failed    int32 `json:"failed"`
+    Skipped   int32 `json:"skipped"`
+    Added     int32 `json:"added,omitempty"`
+    Updated   int32 `json:"updated,omitempty"`
+}
+
+// itemOutcome counts one processed row
+func itemOutcome(err error) itemCounts {
+    switch {
+    case errors.Is(err, errItemUnchanged):
+        return itemCounts{Processed: 1, Skipped: 1}
+    case err != nil:
+        return itemCounts{Processed: 1, Failed: 1}
+    }
+    return itemCounts{Processed: 1, Succeeded: 1}
+}
+
+func (c *itemCounts) add(o itemCounts) {
+    c.Processed += o.Processed
+    c.Succeeded += o.Succeeded
+    c.Failed += o.Failed
+    c.Skipped += o.Skipped
+    c.Added += o.Added
+    c.Updated += o.Updated
+}
+
+// rowPosition identifies a source row by the key it is read in order of:
+// the item ID, after the watermark column value for incremental syncs
+type rowPosition struct {
+    ItemID string     `json:"itemId"`
+    Mark   *time.Time `json:"mark,omitempty"`
+}
+
+// loadCheckpoint decodes a job's checkpoint; jobs without one start over.
+// A checkpoint that cannot be decoded is an error rather than a fresh start,
+// which would upload every row again and report wrong counts.
+func loadCheckpoint(record *models.IngestionJob) (jobCheckpoint, error) {
+    var cp jobCheckpoint
+    if len(record.Checkpoint) > 0 {
+        if err := json.Unmarshal(record.Checkpoint, &cp); err != nil {
+            return jobCheckpoint{}, fmt.Errorf("invalid checkpoint: %w", err)
+        }
+    }
+    if cp.Types == nil {
+        cp.Types = make(map[string]typeCheckpoint)
+    }
+    return cp, nil
+}
+
+// decodeRecentErrors returns the recent errors persisted on a job
+func decodeRecentErrors(record *models.IngestionJob) ([]string, error) {
+    var recentErrors []string
+    if len(record.RecentErrors) > 0 {
+        if err := json.Unmarshal(record.RecentErrors, &recentErrors); err != nil {
+            return nil, fmt.Errorf("invalid recent errors: %w", err)
+        }
+    }
+    return recentErrors, nil
+}
+
+// queryCounts returns the outcomes of the rows a query job's checkpoint
+// covers
+func (cp jobCheckpoint) queryCounts() itemCounts {
+    if cp.Counts == nil {
+        return itemCounts{}
+    }
+    return *cp.Counts
+}
+
+// restore sets the counters to those of the rows a resumed job's checkpoint
+// covers, so rows processed again after it are not counted twice
+func (p *jobProgress) restore(job *models.IngestionJob, counts itemCounts) error {
+    recentErrors, err := decodeRecentErrors(job)
+    if err != nil {
+        return err
+    }
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.totalItems = counts.Processed
+    p.processedItems = counts.Processed
+    p.successCount = counts.Succeeded
+    p.errorCount = counts.Failed
+    p.skippedCount = counts.Skipped
+    if job.StartedAt != nil {
+        p.startTime = *job.StartedAt
+    }
+    p.recentErrors = recentErrors
+    return nil
+}
+
+// isInterruption reports whether an item failed only because its job was
+// stopped, leaving it to be processed again on resume. Items that ran past
+// their own processing timeout have failed.
+func isInterruption(err error) bool {
+    return errors.Is(err, context.Canceled)
+}
+
+// progressCursor tracks rows handed to an ingestion pipeline, which
+// finishes them out of order, and finds the checkpoint: the position of the
+// last row before which every row has finished, and the outcomes of the rows
+// up to it. Rows whose items were interrupted never finish, so a resumed job
+// reads them again; so does an item ID added twice, past its first row.
+type progressCursor struct {
+    mu         sync.Mutex
+    next       int64
+    done       int64
+    last       *rowPosition
+    lastCounts itemCounts
+    counts     itemCounts
+    finished   map[int64]itemCounts
+    pending    map[string]int64
+    positions  map[int64]*rowPosition
+}
+
+// newProgressCursor starts a cursor at a checkpoint
+func newProgressCursor(last *rowPosition, counts itemCounts) *progressCursor {
+    return &progressCursor{
+        last:       last,
+        lastCounts: counts,
+        counts:     counts,
+        finished:   make(map[int64]itemCounts),
+        pending:    make(map[string]int64),
+        positions:  make(map[int64]*rowPosition),
+    }
+}
+
+// add registers a row whose item was added to the pipeline
+func (c *progressCursor) add(itemID string, pos *rowPosition) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.pending[itemID] = c.next
+    c.positions[c.next] = pos
+    c.next++
+}
+
+// skip registers a row that finished without reaching the pipeline
+func (c *progressCursor) skip(pos *rowPosition, outcome itemCounts) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.positions[c.next] = pos
+    c.finishLocked(c.next, outcome)
+    c.next++
+}
+
+// complete records the outcome of a pipeline item
+func (c *progressCursor) complete(itemID string, outcome itemCounts) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    seq, ok := c.pending[itemID]
+    if !ok {
+        return
+    }
+    delete(c.pending, itemID)
+    c.finishLocked(seq, outcome)
+}
+
+func (c *progressCursor) finishLocked(seq int64, outcome itemCounts) {
+    c.finished[seq] = outcome
+    for {
+        outcome, ok := c.finished[c.done]
+        if !ok {
+            return
+        }
+        c.counts.add(outcome)
+        if pos := c.positions[c.done]; pos != nil {
+            c.last = pos
+            c.lastCounts = c.counts
+        }
+        delete(c.finished, c.done)
+        delete(c.positions, c.done)
+        c.done++
+    }
+}
+
+// checkpoint returns the last position and the outcomes of the rows up to it
+func (c *progressCursor) checkpoint() (*rowPosition, itemCounts) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.last, c.lastCounts
+}
+
+// tally returns the outcomes of every row finished in order
+func (c *progressCursor) tally() itemCounts {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.counts
+}
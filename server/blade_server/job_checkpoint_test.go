@@ -0,0 +1,65 @@
+package blade_server
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "testing"
+
+    "blade-ingestion-service/server/utils"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestProgressCursor(t *testing.T) {
+    c := newProgressCursor(nil, itemCounts{})
+    for _, id := range []string{"a", "b", "c", "d"} {
+        c.add(id, &rowPosition{ItemID: id})
+    }
+
+    // Items finishing out of order only advance the checkpoint once every
+    // earlier row has finished
+    c.complete("b", itemOutcome(nil))
+    last, counts := c.checkpoint()
+    assert.Nil(t, last)
+    assert.Zero(t, counts)
+
+    c.complete("a", itemOutcome(errors.New("sink rejected item")))
+    last, counts = c.checkpoint()
+    assert.Equal(t, "b", last.ItemID)
+    assert.Equal(t, itemCounts{Processed: 2, Succeeded: 1, Failed: 1}, counts)
+
+    // An interrupted item never finishes and holds the checkpoint back
+    c.complete("d", itemOutcome(errItemUnchanged))
+    c.skip(nil, itemOutcome(errors.New("unreadable row")))
+    last, counts = c.checkpoint()
+    assert.Equal(t, "b", last.ItemID)
+    assert.Equal(t, itemCounts{Processed: 2, Succeeded: 1, Failed: 1}, counts)
+
+    // Rows without a position are counted with the next row that has one
+    c.complete("c", itemOutcome(nil))
+    last, counts = c.checkpoint()
+    assert.Equal(t, "d", last.ItemID)
+    assert.Equal(t, itemCounts{Processed: 4, Succeeded: 2, Failed: 1, Skipped: 1}, counts)
+    assert.Equal(t, itemCounts{Processed: 5, Succeeded: 2, Failed: 2, Skipped: 1}, c.tally())
+}
+
+func TestIsInterruption(t *testing.T) {
+    assert.True(t, isInterruption(fmt.Errorf("upload skipped: %w", context.Canceled)))
+    assert.False(t, isInterruption(fmt.Errorf("%w after 1m0s", errProcessingTimeout)))
+    assert.False(t, isInterruption(context.DeadlineExceeded))
+}
+
+func TestBuildSyncQueryResumes(t *testing.T) {
+    s := &Server{config: &utils.Config{DBSchema: "blade", MaxRecordsPerQuery: 500}}
+
+    query, params, err := s.buildSyncQuery("maintenance", "blade.blade_maintenance_data", "", utils.Unlimited, nil)
+    assert.NoError(t, err)
+    assert.Equal(t, "SELECT * FROM blade.blade_maintenance_data ORDER BY item_id ASC", query)
+    assert.Empty(t, params)
+
+    query, params, err = s.buildSyncQuery("maintenance", "blade.blade_maintenance_data", "", utils.Unlimited, &rowPosition{ItemID: "MX-100"})
+    assert.NoError(t, err)
+    assert.Equal(t, "SELECT * FROM blade.blade_maintenance_data WHERE item_id > :resume_item ORDER BY item_id ASC", query)
+    assert.Equal(t, "MX-100", *params[0].Value)
+}
@@ -0,0 +1,75 @@
+package blade_server
+
+import (
+    "encoding/json"
+    "strings"
+
+    "blade-ingestion-service/database/models"
+)
+
+// metadataEnricher extracts catalog metadata from an item's decoded data
+type metadataEnricher func(data map[string]interface{}) map[string]interface{}
+
+// liftedColumns are the fields catalog users search on, lifted out of each
+// data type's JSON payload. They must be columns of the data type's typed
+// model, which the tests check.
+var liftedColumns = map[models.BLADEItemType][]string{
+    models.MaintenanceData: {"aircraft_tail", "aircraft_type", "base_location"},
+    models.SortieData:      {"mission_id", "mission_status"},
+    models.DeploymentData:  {"unit_designation", "deployment_location"},
+    models.LogisticsData:   {"shipment_id", "vendor"},
+}
+
+// metadataEnrichers extract each data type's metadata
+var metadataEnrichers = func() map[models.BLADEItemType]metadataEnricher {
+    enrichers := make(map[models.BLADEItemType]metadataEnricher, len(liftedColumns))
+    for itemType, columns := range liftedColumns {
+        enrichers[itemType] = liftFields(columns...)
+    }
+    return enrichers
+}()
+
+// liftFields copies the named columns into metadata under camelCase keys,
+// skipping empty values
+func liftFields(columns ...string) metadataEnricher {
+    return func(data map[string]interface{}) map[string]interface{} {
+        fields := make(map[string]interface{})
+        for _, column := range columns {
+            switch v := data[column].(type) {
+            case nil:
+            case string:
+                if v != "" {
+                    fields[metadataKey(column)] = v
+                }
+            default:
+                fields[metadataKey(column)] = v
+            }
+        }
+        return fields
+    }
+}
+
+// metadataKey converts a column name such as aircraft_tail to aircraftTail
+func metadataKey(column string) string {
+    parts := strings.Split(column, "_")
+    for i := 1; i < len(parts); i++ {
+        if parts[i] != "" {
+            parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+        }
+    }
+    return strings.Join(parts, "")
+}
+
+// EnrichMetadata returns the type-specific metadata for an item. Items whose
+// data cannot be decoded get none.
+func EnrichMetadata(item *models.BLADEItem) map[string]interface{} {
+    enrich, ok := metadataEnrichers[models.GetBLADEItemType(item.DataType)]
+    if !ok || len(item.Data) == 0 {
+        return nil
+    }
+    var data map[string]interface{}
+    if err := json.Unmarshal(item.Data, &data); err != nil {
+        return nil
+    }
+    return enrich(data)
+}
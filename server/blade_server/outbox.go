@@ -0,0 +1,270 @@
+package blade_server
+
+import (
+    "context"
+    "log"
+    "sync/atomic"
+    "time"
+
+    "blade-ingestion-service/database/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+const (
+    // outboxMaxBackoff caps the delay between redeliveries of an entry
+    outboxMaxBackoff = 30 * time.Minute
+
+    // outboxRetention is how long delivered entries are kept
+    outboxRetention = 24 * time.Hour
+)
+
+// outboxLease is how long a delivery holds its entries. It outlasts the
+// upload of a batch so the dispatcher only picks up entries whose delivery
+// was abandoned, e.g. by a crash.
+func (s *Server) outboxLease() time.Duration {
+    if s.config.ProcessingTimeout > 0 {
+        return s.config.ProcessingTimeout + time.Minute
+    }
+    return 10 * time.Minute
+}
+
+// stageItem stores an item together with an outbox entry for its content, in
+// one transaction, when the item is accepted for ingestion. The entry is
+// leased to the caller, which is expected to deliver the item soon.
+func (s *Server) stageItem(ctx context.Context, item *models.BLADEItem) error {
+    now := time.Now()
+    lockedUntil := now.Add(s.outboxLease())
+    return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := saveItem(tx, item); err != nil {
+            return err
+        }
+        return tx.Create(&models.OutboxEntry{
+            ItemID:      item.ItemID,
+            ContentHash: item.ContentHash,
+            Status:      models.OutboxPending,
+            AvailableAt: now,
+            LockedUntil: &lockedUntil,
+        }).Error
+    })
+}
+
+// renewOutbox extends the leases of staged items as their delivery starts,
+// since a batch may wait for an upload slot for longer than the lease
+// taken when it was staged
+func (s *Server) renewOutbox(ctx context.Context, items []*models.BLADEItem) {
+    keys := make([][]interface{}, len(items))
+    for i, item := range items {
+        keys[i] = []interface{}{item.ItemID, item.ContentHash}
+    }
+    err := s.db.WithContext(context.WithoutCancel(ctx)).Model(&models.OutboxEntry{}).
+        Where("(item_id, content_hash) IN ? AND status = ?", keys, models.OutboxPending).
+        Update("locked_until", time.Now().Add(s.outboxLease())).Error
+    if err != nil {
+        log.Printf("Failed to renew outbox leases: %v", err)
+    }
+}
+
+// completeOutbox marks the outbox entries for an item's current content as
+// delivered. It runs in the transaction that records the upload.
+func completeOutbox(tx *gorm.DB, item *models.BLADEItem, now time.Time) error {
+    return tx.Model(&models.OutboxEntry{}).
+        Where("item_id = ? AND content_hash = ? AND status <> ?", item.ItemID, item.ContentHash, models.OutboxDone).
+        Updates(map[string]interface{}{
+            "status":       models.OutboxDone,
+            "delivered_at": now,
+            "locked_until": nil,
+        }).Error
+}
+
+// failOutbox releases the entries of items that failed delivery so the
+// dispatcher retries them with exponential backoff. Entries that reach
+// OutboxMaxAttempts are marked failed; their items stay in the dead-letter
+// store for manual replay.
+func (s *Server) failOutbox(ctx context.Context, items []*models.BLADEItem, errs []error) {
+    db := s.db.WithContext(context.WithoutCancel(ctx))
+
+    statusExpr := gorm.Expr("status")
+    if max := s.config.OutboxMaxAttempts; max > 0 {
+        statusExpr = gorm.Expr("CASE WHEN attempts + 1 >= ? THEN ? ELSE status END", max, models.OutboxFailed)
+    }
+    base := s.uploadConfig.RetryDelay.Seconds()
+    if base <= 0 {
+        base = defaultRetryDelay.Seconds()
+    }
+
+    for i, item := range items {
+        if errs[i] == nil {
+            continue
+        }
+        err := db.Model(&models.OutboxEntry{}).
+            Where("item_id = ? AND content_hash = ? AND status = ?", item.ItemID, item.ContentHash, models.OutboxPending).
+            Updates(map[string]interface{}{
+                "status":       statusExpr,
+                "attempts":     gorm.Expr("attempts + 1"),
+                "last_error":   errs[i].Error(),
+                "locked_until": nil,
+                "available_at": gorm.Expr("NOW() + LEAST(? * POWER(2, attempts), ?) * INTERVAL '1 second'",
+                    base, outboxMaxBackoff.Seconds()),
+            }).Error
+        if err != nil {
+            log.Printf("Failed to update outbox for %s: %v", item.ItemID, err)
+        }
+    }
+}
+
+// startOutboxDispatcher launches the worker that redelivers outbox entries
+// left pending by failed or interrupted ingestions
+func (s *Server) startOutboxDispatcher(ctx context.Context) {
+    s.jobsWG.Add(1)
+    go func() {
+        defer s.jobsWG.Done()
+        s.outboxDispatcher(ctx)
+    }()
+}
+
+// outboxDispatcher delivers due outbox entries until ctx is done
+func (s *Server) outboxDispatcher(ctx context.Context) {
+    interval := s.config.OutboxPollInterval
+    if interval <= 0 {
+        interval = jobPollInterval
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        for ctx.Err() == nil {
+            n, err := s.dispatchOutbox(ctx)
+            if err != nil {
+                log.Printf("Outbox dispatch failed: %v", err)
+                break
+            }
+            if n == 0 {
+                break
+            }
+        }
+        s.pruneOutbox(ctx)
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// claimOutboxEntries leases up to limit due entries to this process
+func (s *Server) claimOutboxEntries(ctx context.Context, limit int) ([]models.OutboxEntry, error) {
+    var entries []models.OutboxEntry
+    err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        now := time.Now()
+        err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+            Where("status = ? AND available_at <= ? AND (locked_until IS NULL OR locked_until < ?)",
+                models.OutboxPending, now, now).
+            Order("available_at, id").
+            Limit(limit).
+            Find(&entries).Error
+        if err != nil || len(entries) == 0 {
+            return err
+        }
+
+        ids := make([]uint, len(entries))
+        for i, e := range entries {
+            ids[i] = e.ID
+        }
+        return tx.Model(&models.OutboxEntry{}).
+            Where("id IN ?", ids).
+            Update("locked_until", now.Add(s.outboxLease())).Error
+    })
+    return entries, err
+}
+
+// dispatchOutbox claims one round of due entries and delivers their items.
+// It returns the number of entries claimed.
+func (s *Server) dispatchOutbox(ctx context.Context) (int, error) {
+    limit := s.config.CatalogBatchSize * s.config.ConcurrentUploads
+    if limit < 1 {
+        limit = 1
+    }
+    entries, err := s.claimOutboxEntries(ctx, limit)
+    if err != nil || len(entries) == 0 {
+        return 0, err
+    }
+
+    itemIDs := make([]string, 0, len(entries))
+    for _, e := range entries {
+        itemIDs = append(itemIDs, e.ItemID)
+    }
+    var items []*models.BLADEItem
+    if err := s.db.WithContext(ctx).Where("item_id IN ?", itemIDs).Find(&items).Error; err != nil {
+        return 0, err
+    }
+    byID := make(map[string]*models.BLADEItem, len(items))
+    for _, item := range items {
+        byID[item.ItemID] = item
+    }
+
+    // Entries whose item was deleted, retracted, changed again or already
+    // delivered have nothing left to send
+    var stale []uint
+    var due []*models.BLADEItem
+    queued := make(map[string]bool)
+    for _, e := range entries {
+        item := byID[e.ItemID]
+        if item == nil || item.RetractedAt != nil || item.ContentHash != e.ContentHash || item.UploadedHash == item.ContentHash {
+            stale = append(stale, e.ID)
+            continue
+        }
+        if queued[item.ItemID] {
+            continue
+        }
+        queued[item.ItemID] = true
+
+        item.LastAction = models.ItemActionCreated
+        if item.UploadedAt != nil {
+            item.LastAction = models.ItemActionUpdated
+        }
+        due = append(due, item)
+    }
+
+    if len(stale) > 0 {
+        err := s.db.WithContext(ctx).Model(&models.OutboxEntry{}).
+            Where("id IN ?", stale).
+            Updates(map[string]interface{}{
+                "status":       models.OutboxDone,
+                "delivered_at": time.Now(),
+                "locked_until": nil,
+            }).Error
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    if len(due) > 0 {
+        var delivered, failed atomic.Int32
+        pipeline := s.newDeliveryPipeline(ctx, func(itemID string, err error) {
+            if err != nil {
+                failed.Add(1)
+                return
+            }
+            delivered.Add(1)
+        })
+        for _, item := range due {
+            pipeline.add(item)
+        }
+        pipeline.close()
+        log.Printf("Outbox redelivered %d items, %d failed", delivered.Load(), failed.Load())
+    }
+    return len(entries), nil
+}
+
+// pruneOutbox deletes delivered entries past outboxRetention
+func (s *Server) pruneOutbox(ctx context.Context) {
+    err := s.db.WithContext(ctx).
+        Where("status = ? AND delivered_at < ?", models.OutboxDone, time.Now().Add(-outboxRetention)).
+        Delete(&models.OutboxEntry{}).Error
+    if err != nil && ctx.Err() == nil {
+        log.Printf("Failed to prune outbox: %v", err)
+    }
+}
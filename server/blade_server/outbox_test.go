@@ -0,0 +1,192 @@
+package blade_server
+
+import (
+    "context"
+    "database/sql/driver"
+    "errors"
+    "regexp"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+// outboxEntryColumns are the outbox_entries columns returned by sqlmock
+var outboxEntryColumns = []string{"id", "item_id", "content_hash", "status", "attempts", "available_at", "locked_until"}
+
+// withinLease matches a time a lease of d after the test started
+type withinLease struct {
+    start time.Time
+    d     time.Duration
+}
+
+func (w withinLease) Match(v driver.Value) bool {
+    t, ok := v.(time.Time)
+    return ok && !t.Before(w.start.Add(w.d)) && t.Before(time.Now().Add(w.d))
+}
+
+func TestStageItemWritesItemAndEntryTogether(t *testing.T) {
+    s, mock := newMockServer(t, &utils.Config{ProcessingTimeout: time.Minute})
+    item := &models.BLADEItem{ItemID: "M-1", DataType: "maintenance", ContentHash: "h1"}
+    start := time.Now()
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(`INSERT INTO "blade_items" .* ON CONFLICT \("item_id"\) DO UPDATE SET`).
+        WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+    mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_entries" ("created_at","updated_at","item_id","content_hash","status","attempts","last_error","available_at","locked_until","delivered_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "id"`)).
+        WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "M-1", "h1", models.OutboxPending, 0, "", sqlmock.AnyArg(),
+            withinLease{start, 2 * time.Minute}, nil).
+        WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+    mock.ExpectCommit()
+
+    require.NoError(t, s.stageItem(context.Background(), item))
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, uint(7), item.ID)
+}
+
+func TestStageItemRollsBackWithoutEntry(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+
+    // An item is never stored without its outbox entry
+    mock.ExpectBegin()
+    mock.ExpectQuery(`INSERT INTO "blade_items"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+    mock.ExpectQuery(`INSERT INTO "outbox_entries"`).WillReturnError(errors.New("disk full"))
+    mock.ExpectRollback()
+
+    err := s.stageItem(context.Background(), &models.BLADEItem{ItemID: "M-1", ContentHash: "h1"})
+    assert.ErrorContains(t, err, "disk full")
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimOutboxEntriesSkipsLockedRows(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+    start := time.Now()
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "outbox_entries" WHERE status = $1 AND available_at <= $2 AND (locked_until IS NULL OR locked_until < $3) ORDER BY available_at, id LIMIT $4 FOR UPDATE SKIP LOCKED`)).
+        WithArgs(models.OutboxPending, sqlmock.AnyArg(), sqlmock.AnyArg(), 10).
+        WillReturnRows(sqlmock.NewRows(outboxEntryColumns).
+            AddRow(4, "M-1", "h1", models.OutboxPending, 1, start, nil).
+            AddRow(9, "M-2", "h2", models.OutboxPending, 0, start, nil))
+    mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_entries" SET "locked_until"=$1,"updated_at"=$2 WHERE id IN ($3,$4)`)).
+        WithArgs(withinLease{start, s.outboxLease()}, sqlmock.AnyArg(), 4, 9).
+        WillReturnResult(sqlmock.NewResult(0, 2))
+    mock.ExpectCommit()
+
+    entries, err := s.claimOutboxEntries(context.Background(), 10)
+    require.NoError(t, err)
+    assert.NoError(t, mock.ExpectationsWereMet())
+    require.Len(t, entries, 2)
+    assert.Equal(t, "M-1", entries[0].ItemID)
+    assert.Equal(t, "M-2", entries[1].ItemID)
+}
+
+func TestClaimOutboxEntriesWithNothingDue(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(`FOR UPDATE SKIP LOCKED`).WillReturnRows(sqlmock.NewRows(outboxEntryColumns))
+    mock.ExpectCommit()
+
+    entries, err := s.claimOutboxEntries(context.Background(), 10)
+    assert.NoError(t, err)
+    assert.Empty(t, entries)
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFailOutboxBacksOffFailedItems(t *testing.T) {
+    s, mock := newMockServer(t, &utils.Config{OutboxMaxAttempts: 5})
+    s.uploadConfig.RetryDelay = 3 * time.Second
+    items := []*models.BLADEItem{
+        {ItemID: "M-1", ContentHash: "h1"},
+        {ItemID: "M-2", ContentHash: "h2"},
+    }
+
+    // Only the failed item is released, with a delay doubling per attempt
+    // up to outboxMaxBackoff, and marked failed on its last attempt
+    mock.ExpectBegin()
+    mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_entries" SET "attempts"=attempts + 1,"available_at"=NOW() + LEAST($1 * POWER(2, attempts), $2) * INTERVAL '1 second',"last_error"=$3,"locked_until"=$4,"status"=CASE WHEN attempts + 1 >= $5 THEN $6 ELSE status END,"updated_at"=$7 WHERE item_id = $8 AND content_hash = $9 AND status = $10`)).
+        WithArgs(3.0, outboxMaxBackoff.Seconds(), "catalog sink: rejected", nil, 5, models.OutboxFailed,
+            sqlmock.AnyArg(), "M-2", "h2", models.OutboxPending).
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectCommit()
+
+    s.failOutbox(context.Background(), items, []error{nil, errors.New("catalog sink: rejected")})
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFailOutboxWithoutAttemptLimit(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectExec(regexp.QuoteMeta(`"status"=status,`)).
+        WithArgs(s.uploadConfig.RetryDelay.Seconds(), outboxMaxBackoff.Seconds(), "rejected", nil,
+            sqlmock.AnyArg(), "M-1", "h1", models.OutboxPending).
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectCommit()
+
+    // Interrupted operations still release their entries
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    s.failOutbox(ctx, []*models.BLADEItem{{ItemID: "M-1", ContentHash: "h1"}}, []error{errors.New("rejected")})
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkUploadedCompletesOutboxInSameTransaction(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+    item := &models.BLADEItem{ItemID: "M-1", ContentHash: "h2", CatalogID: "cat-1", LastAction: models.ItemActionUpdated}
+    item.ID = 7
+
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE "blade_items" SET .* WHERE id = \$\d+`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_entries" SET "delivered_at"=$1,"locked_until"=$2,"status"=$3,"updated_at"=$4 WHERE item_id = $5 AND content_hash = $6 AND status <> $7`)).
+        WithArgs(sqlmock.AnyArg(), nil, models.OutboxDone, sqlmock.AnyArg(), "M-1", "h2", models.OutboxDone).
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectCommit()
+
+    require.NoError(t, s.markUploaded(context.Background(), item))
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, "h2", item.UploadedHash)
+}
+
+func TestMarkUploadedRollsBackWhenOutboxFails(t *testing.T) {
+    s, mock := newMockServer(t, nil)
+    item := &models.BLADEItem{ItemID: "M-1", ContentHash: "h2", UploadedHash: "h1"}
+
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE "blade_items"`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`UPDATE "outbox_entries"`).WillReturnError(errors.New("deadlock"))
+    mock.ExpectRollback()
+
+    // The upload is not recorded, so the entry stays pending for redelivery
+    assert.ErrorContains(t, s.markUploaded(context.Background(), item), "deadlock")
+    assert.NoError(t, mock.ExpectationsWereMet())
+    assert.Equal(t, "h1", item.UploadedHash)
+}
+
+func TestPipelineStagesItemsWhenAdded(t *testing.T) {
+    s, rec := newRecordingServer(t, &utils.Config{CatalogBatchSize: 2, ConcurrentUploads: 1})
+    sink := &fakeSink{name: "fake"}
+
+    result := newIngestionResult()
+    p := s.newIngestPipeline(context.Background(), result.record)
+    p.sinks[0] = []Sink{sink}
+    p.add(&models.BLADEItem{ItemID: "M-1", DataType: "maintenance", Data: []byte(`{"a":1}`)})
+
+    // Staged before a batch is full, let alone handed to a worker
+    assert.Len(t, rec.matching(`INSERT INTO "outbox_entries"`), 1)
+    assert.Empty(t, sink.uploaded)
+
+    p.close()
+    assert.Equal(t, []string{"M-1"}, sink.uploaded)
+
+    // The lease taken at staging is renewed as delivery starts
+    renewals := rec.matching(`UPDATE "outbox_entries" SET "locked_until"=`, `WHERE (item_id, content_hash) IN (($`)
+    require.Len(t, renewals, 1)
+    assert.Contains(t, renewals[0].Args, "M-1")
+}
@@ -0,0 +1,263 @@
+package blade_server
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+)
+
+const (
+    pageTokenVersion = 1
+    defaultPageSize  = 100
+    maxOrderByTerms  = 4
+)
+
+var errInvalidPageToken = errors.New("invalid page token")
+
+// orderTerm is one validated column of an ORDER BY clause
+type orderTerm struct {
+    Column string
+    Desc   bool
+}
+
+// parseOrderBy validates an orderBy expression such as "priority DESC,
+// work_order" against the data type's columns: the JSON fields of its typed
+// model (see FilterColumns) plus classification. item_id is appended as the
+// final tiebreaker so every ordering is total and pages are deterministic.
+func parseOrderBy(dataType, orderBy string) ([]orderTerm, error) {
+    columns := FilterColumns(dataType)
+    if columns == nil {
+        return nil, fmt.Errorf("unknown data type %q", dataType)
+    }
+
+    var terms []orderTerm
+    seen := make(map[string]bool)
+    if strings.TrimSpace(orderBy) != "" {
+        for _, part := range strings.Split(orderBy, ",") {
+            fields := strings.Fields(part)
+            if len(fields) == 0 || len(fields) > 2 {
+                return nil, fmt.Errorf("invalid orderBy term %q", strings.TrimSpace(part))
+            }
+
+            term := orderTerm{Column: fields[0]}
+            if _, ok := columns[term.Column]; !ok {
+                return nil, fmt.Errorf("unknown orderBy column %q", term.Column)
+            }
+            if seen[term.Column] {
+                return nil, fmt.Errorf("duplicate orderBy column %q", term.Column)
+            }
+            if len(fields) == 2 {
+                switch strings.ToUpper(fields[1]) {
+                case "ASC":
+                case "DESC":
+                    term.Desc = true
+                default:
+                    return nil, fmt.Errorf("invalid sort direction %q", fields[1])
+                }
+            }
+
+            seen[term.Column] = true
+            terms = append(terms, term)
+        }
+    }
+
+    if len(terms) > maxOrderByTerms {
+        return nil, fmt.Errorf("orderBy accepts at most %d columns", maxOrderByTerms)
+    }
+    if !seen["item_id"] {
+        terms = append(terms, orderTerm{Column: "item_id"})
+    }
+    return terms, nil
+}
+
+// orderByClause renders terms as SQL. NULLs sort last in both directions,
+// which keysetPredicate relies on.
+func orderByClause(terms []orderTerm) string {
+    parts := make([]string, len(terms))
+    for i, t := range terms {
+        dir := "ASC"
+        if t.Desc {
+            dir = "DESC"
+        }
+        parts[i] = fmt.Sprintf("%s %s NULLS LAST", t.Column, dir)
+    }
+    return strings.Join(parts, ", ")
+}
+
+// keysetPredicate builds a WHERE fragment selecting the rows that sort after
+// the cursor values. For terms t1..tn it expands to
+//
+//     (t1 after v1) OR (t1 = v1 AND t2 after v2) OR ...
+//
+// where "after" honours the sort direction and NULLS LAST.
+func keysetPredicate(terms []orderTerm, values []interface{}) (string, []StatementParameter) {
+    var params []StatementParameter
+    markers := make([]string, len(terms))
+    for i, v := range values {
+        if v == nil {
+            continue
+        }
+        name := fmt.Sprintf("c%d", i)
+        params = append(params, NewParameter(name, v))
+        markers[i] = ":" + name
+    }
+
+    var disjuncts []string
+    for i, t := range terms {
+        // Nothing sorts after NULL when NULLs are last
+        if values[i] == nil {
+            continue
+        }
+
+        var conj []string
+        for j := 0; j < i; j++ {
+            if values[j] == nil {
+                conj = append(conj, terms[j].Column+" IS NULL")
+            } else {
+                conj = append(conj, fmt.Sprintf("%s = %s", terms[j].Column, markers[j]))
+            }
+        }
+
+        op := ">"
+        if t.Desc {
+            op = "<"
+        }
+        conj = append(conj, fmt.Sprintf("(%s %s %s OR %s IS NULL)", t.Column, op, markers[i], t.Column))
+        disjuncts = append(disjuncts, "("+strings.Join(conj, " AND ")+")")
+    }
+
+    if len(disjuncts) == 0 {
+        return "FALSE", nil
+    }
+    return "(" + strings.Join(disjuncts, " OR ") + ")", params
+}
+
+// pageCursor is the signed content of a page token
+type pageCursor struct {
+    Version  int           `json:"v"`
+    DataType string        `json:"t"`
+    Query    string        `json:"q"`
+    Values   []interface{} `json:"k"`
+}
+
+// pageTokenCodec signs and verifies page tokens so clients cannot alter the
+// cursor or reuse a token with a different query
+type pageTokenCodec struct {
+    secret []byte
+}
+
+// newPageTokenCodec creates a codec. Without a configured secret a random one
+// is generated, so tokens do not survive restarts or work across replicas.
+func newPageTokenCodec(secret string) *pageTokenCodec {
+    if secret != "" {
+        return &pageTokenCodec{secret: []byte(secret)}
+    }
+
+    key := make([]byte, 32)
+    if _, err := rand.Read(key); err != nil {
+        panic(fmt.Sprintf("failed to generate page token secret: %v", err))
+    }
+    log.Printf("Warning: PAGE_TOKEN_SECRET not set, page tokens are only valid for this process")
+    return &pageTokenCodec{secret: key}
+}
+
+func (c *pageTokenCodec) sign(payload []byte) []byte {
+    mac := hmac.New(sha256.New, c.secret)
+    mac.Write(payload)
+    return mac.Sum(nil)
+}
+
+// encode serializes and signs a cursor
+func (c *pageTokenCodec) encode(cursor pageCursor) (string, error) {
+    cursor.Version = pageTokenVersion
+    payload, err := json.Marshal(cursor)
+    if err != nil {
+        return "", fmt.Errorf("failed to encode page token: %w", err)
+    }
+    enc := base64.RawURLEncoding
+    return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.sign(payload)), nil
+}
+
+// decode verifies a token's signature and returns its cursor
+func (c *pageTokenCodec) decode(token string) (*pageCursor, error) {
+    enc := base64.RawURLEncoding
+    payloadPart, sigPart, ok := strings.Cut(token, ".")
+    if !ok {
+        return nil, errInvalidPageToken
+    }
+    payload, err := enc.DecodeString(payloadPart)
+    if err != nil {
+        return nil, errInvalidPageToken
+    }
+    sig, err := enc.DecodeString(sigPart)
+    if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+        return nil, errInvalidPageToken
+    }
+
+    var cursor pageCursor
+    dec := json.NewDecoder(bytes.NewReader(payload))
+    dec.UseNumber()
+    if err := dec.Decode(&cursor); err != nil || cursor.Version != pageTokenVersion {
+        return nil, errInvalidPageToken
+    }
+    return &cursor, nil
+}
+
+// queryFingerprint identifies the filter and ordering a token was issued for
+func queryFingerprint(filter string, terms []orderTerm) string {
+    sum := sha256.Sum256([]byte(strings.TrimSpace(filter) + "\x00" + orderByClause(terms)))
+    return hex.EncodeToString(sum[:16])
+}
+
+// cursorValues extracts the ordering values of the last row on a page
+func cursorValues(row map[string]interface{}, terms []orderTerm) []interface{} {
+    values := make([]interface{}, len(terms))
+    for i, t := range terms {
+        values[i] = row[t.Column]
+    }
+    return values
+}
+
+// restoreCursorValues converts values decoded from a token back to the Go
+// types used when binding, based on each column's kind
+func restoreCursorValues(dataType string, terms []orderTerm, values []interface{}) ([]interface{}, error) {
+    if len(values) != len(terms) {
+        return nil, errInvalidPageToken
+    }
+
+    columns := FilterColumns(dataType)
+    restored := make([]interface{}, len(values))
+    for i, v := range values {
+        switch val := v.(type) {
+        case nil, bool:
+            restored[i] = val
+        case json.Number:
+            if n, err := val.Int64(); err == nil {
+                restored[i] = n
+            } else if f, err := val.Float64(); err == nil {
+                restored[i] = f
+            } else {
+                return nil, errInvalidPageToken
+            }
+        case string:
+            restored[i] = val
+            if columns[terms[i].Column] == kindTime {
+                if ts, err := time.Parse(time.RFC3339Nano, val); err == nil {
+                    restored[i] = ts
+                }
+            }
+        default:
+            return nil, errInvalidPageToken
+        }
+    }
+    return restored, nil
+}
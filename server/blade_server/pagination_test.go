@@ -0,0 +1,51 @@
+package blade_server
+
+import (
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestParseOrderBy(t *testing.T) {
+    terms, err := parseOrderBy("maintenance", "priority DESC, next_scheduled_date")
+    assert.NoError(t, err)
+    assert.Equal(t, []orderTerm{{"priority", true}, {"next_scheduled_date", false}, {"item_id", false}}, terms)
+    assert.Equal(t, "priority DESC NULLS LAST, next_scheduled_date ASC NULLS LAST, item_id ASC NULLS LAST", orderByClause(terms))
+
+    for _, orderBy := range []string{"unknown_col", "priority SIDEWAYS", "priority; DROP TABLE x", "priority, priority"} {
+        _, err := parseOrderBy("maintenance", orderBy)
+        assert.Error(t, err, orderBy)
+    }
+}
+
+func TestPageTokenRoundTrip(t *testing.T) {
+    codec := newPageTokenCodec("test-secret")
+    terms := []orderTerm{{"next_scheduled_date", true}, {"item_id", false}}
+    when := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+    token, err := codec.encode(pageCursor{
+        DataType: "maintenance",
+        Query:    queryFingerprint("priority = 'HIGH'", terms),
+        Values:   cursorValues(map[string]interface{}{"next_scheduled_date": when, "item_id": "MAINT-9"}, terms),
+    })
+    assert.NoError(t, err)
+
+    cursor, err := codec.decode(token)
+    assert.NoError(t, err)
+    assert.Equal(t, queryFingerprint("priority = 'HIGH'", terms), cursor.Query)
+
+    values, err := restoreCursorValues("maintenance", terms, cursor.Values)
+    assert.NoError(t, err)
+    assert.Equal(t, []interface{}{when, "MAINT-9"}, values)
+
+    where, params := keysetPredicate(terms, values)
+    assert.Equal(t, "(((next_scheduled_date < :c0 OR next_scheduled_date IS NULL)) OR (next_scheduled_date = :c0 AND (item_id > :c1 OR item_id IS NULL)))", where)
+    assert.Len(t, params, 2)
+
+    // Tampered payloads and tokens signed with another secret are rejected
+    _, err = codec.decode("x" + token)
+    assert.ErrorIs(t, err, errInvalidPageToken)
+    _, err = newPageTokenCodec("other-secret").decode(token)
+    assert.ErrorIs(t, err, errInvalidPageToken)
+}
@@ -0,0 +1,1082 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "strconv"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "blade-ingestion-service/database/datasource"
+    "blade-ingestion-service/database/models"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/utils"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/emptypb"
+    "google.golang.org/protobuf/types/known/structpb"
+    "google.golang.org/protobuf/types/known/timestamppb"
+    "golang.org/x/time/rate"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// ServiceVersion is reported by the health check endpoint
+const ServiceVersion = "1.0.0"
+
+// Server implements the BLADEIngestionService gRPC API
+type Server struct {
+    pb.UnimplementedBLADEIngestionServiceServer
+
+    config       *utils.Config
+    db           *gorm.DB
+    databricks   *DatabricksClient
+    uploader     *CatalogUploader
+    uploadConfig *utils.CatalogUploadConfig
+    pageTokens   *pageTokenCodec
+    startTime    time.Time
+
+    // Shared by every upload so the catalog sees at most RateLimitPerSecond
+    // items from at most ConcurrentUploads uploads at a time
+    uploadLimiter *rate.Limiter
+    uploadSlots   chan struct{}
+
+    // Sync job state; only one sync runs at a time
+    syncMu      sync.Mutex
+    currentSync *SyncJob
+
+    // Query jobs running on this instance, and a signal to wake idle workers
+    jobsMu    sync.RWMutex
+    queryJobs map[string]*QueryJob
+    jobWakeup chan struct{}
+
+    // Runs data source syncs on their schedules
+    scheduler *syncScheduler
+
+    // Tracks in-flight background jobs for graceful shutdown; jobs cancelled
+    // while shuttingDown are left to resume in the next process
+    jobsWG       sync.WaitGroup
+    shuttingDown atomic.Bool
+}
+
+// NewServer creates a new BLADE ingestion server
+func NewServer(config *utils.Config, db *gorm.DB) *Server {
+    databricks := NewDatabricksClient(config.MockDatabricksURL, config.MockDatabricksToken, config.MockWarehouseID)
+    if config.MockResultDisposition != "" {
+        if err := databricks.SetResultDisposition(config.MockResultDisposition); err != nil {
+            log.Printf("Warning: %v, using %s", err, DispositionInline)
+        }
+    }
+
+    uploadConfig := config.NewCatalogUploadConfig()
+    s := &Server{
+        config:        config,
+        db:            db,
+        databricks:    databricks,
+        uploader:      NewCatalogUploader(config.CatalogURL, config.CatalogAuthToken, uploadConfig),
+        uploadConfig:  uploadConfig,
+        pageTokens:    newPageTokenCodec(config.PageTokenSecret),
+        startTime:     time.Now(),
+        uploadLimiter: newUploadLimiter(config.RateLimitPerSecond, config.CatalogBatchSize),
+        uploadSlots:   newUploadSlots(config.ConcurrentUploads),
+        queryJobs:     make(map[string]*QueryJob),
+        jobWakeup:     make(chan struct{}, 1),
+    }
+    s.scheduler = newSyncScheduler(s)
+    return s
+}
+
+// Start recovers persisted job state and launches background workers.
+// Workers stop picking up new work once ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+    if err := s.recoverQueryJobs(); err != nil {
+        return err
+    }
+    if err := s.recoverSyncJobs(); err != nil {
+        return err
+    }
+    s.startQueryWorkers(ctx)
+    s.startOutboxDispatcher(ctx)
+    s.scheduler.start(ctx)
+    return nil
+}
+
+// Shutdown waits for in-flight jobs to finish, cancelling them if ctx expires first
+func (s *Server) Shutdown(ctx context.Context) error {
+    done := make(chan struct{})
+    go func() {
+        s.jobsWG.Wait()
+        close(done)
+    }()
+
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+    }
+
+    log.Printf("Shutdown deadline reached, cancelling running jobs")
+    s.cancelRunningJobs()
+    <-done
+    return ctx.Err()
+}
+
+// cancelRunningJobs cancels every running sync and query job
+func (s *Server) cancelRunningJobs() {
+    s.shuttingDown.Store(true)
+
+    s.syncMu.Lock()
+    if s.currentSync != nil {
+        s.currentSync.cancel()
+    }
+    s.syncMu.Unlock()
+
+    s.jobsMu.RLock()
+    for _, job := range s.queryJobs {
+        job.cancel()
+    }
+    s.jobsMu.RUnlock()
+}
+
+// ============= Configuration Endpoints =============
+
+// AddBLADESource registers a new Databricks data source
+func (s *Server) AddBLADESource(ctx context.Context, req *pb.DataSource) (*emptypb.Empty, error) {
+    if req.GetName() == "" {
+        return nil, status.Error(codes.InvalidArgument, "name is required")
+    }
+    if !s.config.IsBLADEDataType(req.GetDataType()) {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid data type: %s", req.GetDataType())
+    }
+
+    var existing datasource.DataSource
+    err := s.db.WithContext(ctx).Where("type_name = ?", req.GetName()).First(&existing).Error
+    if err == nil {
+        return nil, status.Errorf(codes.AlreadyExists, "data source %s already exists", req.GetName())
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, status.Errorf(codes.Internal, "failed to look up data source: %v", err)
+    }
+
+    source := &datasource.DataSource{
+        TypeName:    req.GetName(),
+        DisplayName: req.GetDisplayName(),
+        DataType:    req.GetDataType(),
+        Enabled:     req.GetEnabled(),
+        WarehouseID: s.config.MockWarehouseID,
+        TableName:   s.defaultTableName(req.GetDataType()),
+    }
+
+    params := req.GetConfig().AsMap()
+    if err := source.SetParameters(params); err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+    }
+    if _, err := parseSinkConfigs(params); err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+    }
+    if v, ok := params["warehouse_id"].(string); ok && v != "" {
+        source.WarehouseID = v
+    }
+    if v, ok := params["catalog"].(string); ok {
+        source.CatalogName = v
+    }
+    if v, ok := params["schema"].(string); ok {
+        source.SchemaName = v
+    }
+    if v, ok := params["table"].(string); ok && v != "" {
+        source.TableName = v
+    }
+    if err := ValidateTableName(source.GetFullTableName()); err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+    }
+    if source.WatermarkColumn, err = watermarkColumn(source.DataType, params); err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+    }
+    syncEnabled, schedule, err := parseSyncSchedule(params)
+    if err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+    }
+    source.SyncEnabled = syncEnabled
+    source.SyncSchedule = schedule.Spec
+
+    if err := s.db.WithContext(ctx).Create(source).Error; err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to save data source: %v", err)
+    }
+
+    s.scheduler.wake()
+
+    log.Printf("Added BLADE data source %s (%s)", source.TypeName, source.DataType)
+    return &emptypb.Empty{}, nil
+}
+
+// ListBLADESources lists all configured data sources
+func (s *Server) ListBLADESources(ctx context.Context, _ *emptypb.Empty) (*pb.DataSourceList, error) {
+    var sources []datasource.DataSource
+    if err := s.db.WithContext(ctx).Order("type_name").Find(&sources).Error; err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to list data sources: %v", err)
+    }
+
+    resp := &pb.DataSourceList{}
+    for i := range sources {
+        pbSource, err := toProtoDataSource(&sources[i])
+        if err != nil {
+            return nil, status.Errorf(codes.Internal, "failed to convert data source: %v", err)
+        }
+        resp.DataSources = append(resp.DataSources, pbSource)
+    }
+
+    return resp, nil
+}
+
+// RemoveBLADESource removes a configured data source
+func (s *Server) RemoveBLADESource(ctx context.Context, req *pb.DataSourceRequest) (*emptypb.Empty, error) {
+    if req.GetName() == "" {
+        return nil, status.Error(codes.InvalidArgument, "name is required")
+    }
+
+    result := s.db.WithContext(ctx).Where("type_name = ?", req.GetName()).Delete(&datasource.DataSource{})
+    if result.Error != nil {
+        return nil, status.Errorf(codes.Internal, "failed to remove data source: %v", result.Error)
+    }
+    if result.RowsAffected == 0 {
+        return nil, status.Errorf(codes.NotFound, "data source %s not found", req.GetName())
+    }
+
+    s.scheduler.wake()
+
+    log.Printf("Removed BLADE data source %s", req.GetName())
+    return &emptypb.Empty{}, nil
+}
+
+// ============= Query Endpoints =============
+
+// QueryBLADE queries BLADE data from Databricks
+func (s *Server) QueryBLADE(ctx context.Context, req *pb.BLADEQuery) (*pb.BLADEQueryResponse, error) {
+    dataType := req.GetDataType()
+    if !s.config.IsBLADEDataType(dataType) {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid data type: %s", dataType)
+    }
+    if req.GetLimit() < 0 || req.GetOffset() < 0 {
+        return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+    }
+
+    terms, err := parseOrderBy(dataType, req.GetOrderBy())
+    if err != nil {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
+    where, params, err := CompileFilter(dataType, req.GetFilter())
+    if err != nil {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
+
+    pageSize := int(req.GetLimit())
+    if max := s.config.MaxRecordsPerQuery; max > 0 && (pageSize == 0 || pageSize > max) {
+        pageSize = max
+    }
+    if pageSize == 0 {
+        pageSize = defaultPageSize
+    }
+
+    // Continue after the cursor when a page token is supplied
+    fingerprint := queryFingerprint(req.GetFilter(), terms)
+    pageWhere, pageParams := where, params
+    if token := req.GetPageToken(); token != "" {
+        if req.GetOffset() > 0 {
+            return nil, status.Error(codes.InvalidArgument, "offset cannot be combined with a page token")
+        }
+        cursor, err := s.pageTokens.decode(token)
+        if err != nil {
+            return nil, status.Error(codes.InvalidArgument, err.Error())
+        }
+        if cursor.DataType != dataType || cursor.Query != fingerprint {
+            return nil, status.Error(codes.InvalidArgument, "page token does not match query")
+        }
+        values, err := restoreCursorValues(dataType, terms, cursor.Values)
+        if err != nil {
+            return nil, status.Error(codes.InvalidArgument, err.Error())
+        }
+
+        keyset, keyParams := keysetPredicate(terms, values)
+        if pageWhere == "" {
+            pageWhere = keyset
+        } else {
+            pageWhere = fmt.Sprintf("(%s) AND %s", pageWhere, keyset)
+        }
+        pageParams = append(append([]StatementParameter{}, params...), keyParams...)
+    }
+
+    // Fetch one extra row to learn whether another page follows
+    table, err := s.tableNameFor(ctx, dataType)
+    if err != nil {
+        return nil, err
+    }
+    query := s.config.GetTableQuery(table, pageWhere, orderByClause(terms), pageSize+1, int(req.GetOffset()))
+    rows, err := s.databricks.ExecuteQuery(ctx, query, pageParams...)
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "query failed: %v", err)
+    }
+
+    total, err := s.countRows(ctx, table, where, params)
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "count query failed: %v", err)
+    }
+
+    resp := &pb.BLADEQueryResponse{TotalCount: int32(total)}
+    if len(rows) > pageSize {
+        rows = rows[:pageSize]
+        resp.NextPageToken, err = s.pageTokens.encode(pageCursor{
+            DataType: dataType,
+            Query:    fingerprint,
+            Values:   cursorValues(rows[len(rows)-1], terms),
+        })
+        if err != nil {
+            return nil, status.Errorf(codes.Internal, "%v", err)
+        }
+    }
+
+    for _, row := range rows {
+        item, err := TransformToBLADEItem(dataType, row)
+        if err != nil {
+            return nil, status.Errorf(codes.Internal, "failed to transform row: %v", err)
+        }
+        pbItem, err := toProtoItem(item)
+        if err != nil {
+            return nil, status.Errorf(codes.Internal, "failed to convert item: %v", err)
+        }
+        resp.Items = append(resp.Items, pbItem)
+    }
+
+    return resp, nil
+}
+
+// countRows returns the number of rows of a table matching a compiled filter
+func (s *Server) countRows(ctx context.Context, table, where string, params []StatementParameter) (int64, error) {
+    rows, err := s.databricks.ExecuteQuery(ctx, s.config.GetTableCountQuery(table, where), params...)
+    if err != nil {
+        return 0, err
+    }
+    if len(rows) == 0 {
+        return 0, fmt.Errorf("count query returned no rows")
+    }
+
+    switch v := rows[0]["total_count"].(type) {
+    case int64:
+        return v, nil
+    case nil:
+        return 0, fmt.Errorf("count query returned no total_count")
+    default:
+        return decodeInt(v)
+    }
+}
+
+// GetBLADEItem fetches a single BLADE item from Databricks
+func (s *Server) GetBLADEItem(ctx context.Context, req *pb.BLADEItemRequest) (*pb.BLADEItem, error) {
+    if err := s.validateItemRequest(req); err != nil {
+        return nil, err
+    }
+
+    item, err := s.fetchItem(ctx, req.GetDataType(), req.GetItemId())
+    if err != nil {
+        return nil, err
+    }
+    if err := s.loadUploadState(ctx, item); err != nil {
+        return nil, status.Errorf(codes.Internal, "%v", err)
+    }
+
+    pbItem, err := toProtoItem(item)
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to convert item: %v", err)
+    }
+    return pbItem, nil
+}
+
+// ============= Ingestion Endpoints =============
+
+// IngestBLADEItem fetches a BLADE item and ingests it into the catalog
+func (s *Server) IngestBLADEItem(ctx context.Context, req *pb.BLADEItemRequest) (*pb.IngestionResponse, error) {
+    if err := s.validateItemRequest(req); err != nil {
+        return nil, err
+    }
+
+    result := newIngestionResult()
+    pipeline := s.newRequestPipeline(ctx, result.record)
+    defer pipeline.close()
+
+    item, err := s.fetchItem(pipeline.ctx, req.GetDataType(), req.GetItemId())
+    if status.Code(err) == codes.NotFound {
+        // The row is gone from the source; retract any catalog copy
+        retracted, rerr := s.retractItem(pipeline.ctx, req.GetItemId())
+        if rerr != nil {
+            return nil, status.Errorf(codes.Internal, "%v", rerr)
+        }
+        if retracted {
+            result.recordRetracted()
+            return s.finishIngestion(pipeline, result), nil
+        }
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if extra := req.GetMetadata().AsMap(); len(extra) > 0 {
+        if err := mergeMetadata(item, extra); err != nil {
+            return nil, status.Errorf(codes.InvalidArgument, "invalid metadata: %v", err)
+        }
+    }
+
+    pipeline.add(item)
+    return s.finishIngestion(pipeline, result), nil
+}
+
+// BulkIngestBLADE ingests multiple BLADE items by ID or filter
+func (s *Server) BulkIngestBLADE(ctx context.Context, req *pb.BulkIngestionRequest) (*pb.IngestionResponse, error) {
+    if !s.config.IsBLADEDataType(req.GetDataType()) {
+        return nil, status.Errorf(codes.InvalidArgument, "invalid data type: %s", req.GetDataType())
+    }
+
+    extra := make(map[string]interface{}, len(req.GetMetadata()))
+    for k, v := range req.GetMetadata() {
+        extra[k] = v
+    }
+
+    result := newIngestionResult()
+    pipeline := s.newRequestPipeline(ctx, result.record)
+    defer pipeline.close()
+    ctx = pipeline.ctx
+
+    if len(req.GetItemIds()) > 0 {
+        for _, itemID := range req.GetItemIds() {
+            if ctx.Err() != nil {
+                break
+            }
+            item, err := s.fetchItem(ctx, req.GetDataType(), itemID)
+            if status.Code(err) == codes.NotFound {
+                if retracted, rerr := s.retractItem(ctx, itemID); rerr != nil {
+                    err = rerr
+                } else if retracted {
+                    result.recordRetracted()
+                    continue
+                }
+            }
+            if err == nil && len(extra) > 0 {
+                err = mergeMetadata(item, extra)
+            }
+            if err != nil {
+                result.record(itemID, err)
+                continue
+            }
+            pipeline.add(item)
+        }
+        return s.finishIngestion(pipeline, result), nil
+    }
+
+    query, params, err := s.buildFilteredQuery(ctx, req.GetDataType(), req.GetFilter(), int(req.GetMaxItems()))
+    if err != nil {
+        return nil, err
+    }
+    it, err := s.databricks.StreamQuery(ctx, query, params...)
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "query failed: %v", err)
+    }
+    defer it.Close()
+
+    for it.Next() {
+        var item *models.BLADEItem
+        row, err := it.Row()
+        if err == nil {
+            item, err = TransformToBLADEItem(req.GetDataType(), row)
+        }
+        if err == nil && len(extra) > 0 {
+            err = mergeMetadata(item, extra)
+        }
+        if err != nil {
+            itemID := ""
+            if item != nil {
+                itemID = item.ItemID
+            }
+            result.record(itemID, err)
+            continue
+        }
+        pipeline.add(item)
+    }
+    if err := it.Err(); err != nil {
+        result.abort(fmt.Errorf("query aborted: %w", err))
+    }
+
+    return s.finishIngestion(pipeline, result), nil
+}
+
+// finishIngestion waits for a pipeline to drain and builds the response
+func (s *Server) finishIngestion(pipeline *ingestPipeline, result *ingestionResult) *pb.IngestionResponse {
+    pipeline.close()
+    result.retries = int(pipeline.retries.Load())
+    return result.toProto()
+}
+
+// ============= Health Check =============
+
+// HealthCheck reports the health of the service and its dependencies
+func (s *Server) HealthCheck(ctx context.Context, _ *emptypb.Empty) (*pb.HealthResponse, error) {
+    services := map[string]string{}
+    overall := "healthy"
+
+    sqlDB, err := s.db.DB()
+    if err == nil {
+        err = sqlDB.PingContext(ctx)
+    }
+    if err != nil {
+        services["database"] = "unhealthy: " + err.Error()
+        overall = "degraded"
+    } else {
+        services["database"] = "healthy"
+    }
+
+    services["databricks"] = s.config.MockDatabricksURL
+    services["catalog"] = s.config.CatalogURL
+
+    return &pb.HealthResponse{
+        Status:   overall,
+        Version:  ServiceVersion,
+        Services: services,
+        Uptime:   time.Since(s.startTime).Round(time.Second).String(),
+    }, nil
+}
+
+// ============= Internal helpers =============
+
+// validateItemRequest validates a data type / item ID pair
+func (s *Server) validateItemRequest(req *pb.BLADEItemRequest) error {
+    if !s.config.IsBLADEDataType(req.GetDataType()) {
+        return status.Errorf(codes.InvalidArgument, "invalid data type: %s", req.GetDataType())
+    }
+    if req.GetItemId() == "" {
+        return status.Error(codes.InvalidArgument, "itemId is required")
+    }
+    return nil
+}
+
+// fetchItem fetches a single item from its data source and transforms it
+func (s *Server) fetchItem(ctx context.Context, dataType, itemID string) (*models.BLADEItem, error) {
+    source, err := s.itemSource(ctx, dataType, itemID)
+    if err != nil {
+        return nil, err
+    }
+    row, err := s.databricks.FetchBLADEItem(ctx, dataType, itemID, s.tableFor(source, dataType))
+    if err != nil {
+        if status.Code(err) == codes.NotFound {
+            return nil, err
+        }
+        return nil, status.Errorf(codes.Internal, "failed to fetch item: %v", err)
+    }
+
+    item, err := TransformToBLADEItem(dataType, row)
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to transform item: %v", err)
+    }
+    if source != nil {
+        item.DataSourceID = source.ID
+    }
+    return item, nil
+}
+
+// errItemUnchanged marks an item skipped because its content matches the
+// last successful upload; it is reported as skipped rather than failed
+var errItemUnchanged = errors.New("content unchanged since last upload")
+
+// prepareItem validates an item and stages it with an outbox entry ahead of
+// upload. It returns errItemUnchanged when the sinks already have this content.
+func (s *Server) prepareItem(ctx context.Context, item *models.BLADEItem) error {
+    if s.config.EnableDataValidation && !models.ValidateClassificationMarking(item.ClassificationMarking) {
+        return fmt.Errorf("invalid classification marking: %s", item.ClassificationMarking)
+    }
+
+    hash, err := item.ComputeContentHash()
+    if err != nil {
+        return err
+    }
+    item.ContentHash = hash
+
+    var stored models.BLADEItem
+    err = s.db.WithContext(ctx).Select("uploaded_hash", "catalog_id", "uploaded_at", "retracted_at", "delivered_sinks", "data_source_id").
+        Where("item_id = ?", item.ItemID).
+        Limit(1).Find(&stored).Error
+    if err != nil {
+        return fmt.Errorf("failed to look up item: %w", err)
+    }
+    if s.uploadConfig.SkipDuplicates && stored.UploadedHash == hash {
+        return errItemUnchanged
+    }
+
+    // Items already uploaded are updated in place and only sent to sinks
+    // that have not accepted this content; retracted items are uploaded as
+    // new entries
+    item.LastAction = models.ItemActionCreated
+    if stored.RetractedAt == nil {
+        item.CatalogID = stored.CatalogID
+        item.DeliveredSinks = stored.DeliveredSinks
+        if stored.UploadedAt != nil {
+            item.LastAction = models.ItemActionUpdated
+        }
+    }
+
+    // Items read without a source, such as dead letters and query job rows,
+    // keep the one they were stored with; new ones take their data type's
+    if item.DataSourceID == 0 {
+        item.DataSourceID = stored.DataSourceID
+    }
+    if item.DataSourceID == 0 {
+        source, err := s.defaultSource(ctx, item.DataType)
+        if err != nil {
+            return err
+        }
+        if source != nil {
+            item.DataSourceID = source.ID
+        }
+    }
+
+    return s.stageItem(ctx, item)
+}
+
+// recordDelivery saves the sinks that accepted items and the catalog IDs
+// they were assigned. Writes outlive ctx so an accepted item is never sent
+// to the same sink again.
+func (s *Server) recordDelivery(ctx context.Context, items []*models.BLADEItem) error {
+    if len(items) == 0 {
+        return nil
+    }
+
+    err := s.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+        for _, item := range items {
+            err := tx.Model(&models.BLADEItem{}).
+                Where("id = ?", item.ID).
+                Updates(map[string]interface{}{
+                    "catalog_id":      item.CatalogID,
+                    "delivered_sinks": item.DeliveredSinks,
+                }).Error
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return fmt.Errorf("failed to record delivery: %w", err)
+    }
+    return nil
+}
+
+// markUploaded records the catalog ID, upload time and uploaded content of
+// items accepted by every sink and completes their outbox entries
+func (s *Server) markUploaded(ctx context.Context, items ...*models.BLADEItem) error {
+    if len(items) == 0 {
+        return nil
+    }
+
+    now := time.Now()
+    err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        for _, item := range items {
+            err := tx.Model(&models.BLADEItem{}).
+                Where("id = ?", item.ID).
+                Updates(map[string]interface{}{
+                    "catalog_id":     item.CatalogID,
+                    "uploaded_at":    now,
+                    "uploaded_hash":  gorm.Expr("content_hash"),
+                    "last_action":    item.LastAction,
+                    "last_action_at": now,
+                    "retracted_at":   nil,
+                }).Error
+            if err != nil {
+                return err
+            }
+            if err := completeOutbox(tx, item, now); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return fmt.Errorf("failed to record upload: %w", err)
+    }
+
+    for _, item := range items {
+        item.UploadedAt = &now
+        item.UploadedHash = item.ContentHash
+        item.LastActionAt = &now
+        item.RetractedAt = nil
+    }
+    return nil
+}
+
+// retractItem removes a previously uploaded item from its sinks and records
+// the retraction. Items never uploaded or already retracted are
+// left alone.
+func (s *Server) retractItem(ctx context.Context, itemID string) (bool, error) {
+    var item models.BLADEItem
+    err := s.db.WithContext(ctx).
+        Where("item_id = ? AND uploaded_at IS NOT NULL AND retracted_at IS NULL", itemID).
+        Limit(1).Find(&item).Error
+    if err != nil {
+        return false, fmt.Errorf("failed to look up item: %w", err)
+    }
+    if item.ID == 0 {
+        return false, nil
+    }
+
+    if err := s.uploadLimiter.Wait(ctx); err != nil {
+        return false, err
+    }
+    sinks, err := s.sinksFor(ctx, item.DataSourceID)
+    if err != nil {
+        return false, err
+    }
+    for _, sink := range sinks {
+        if err := sink.Retract(ctx, &item); err != nil {
+            return false, fmt.Errorf("failed to retract %s from %s sink: %w", itemID, sink.Name(), err)
+        }
+    }
+
+    now := time.Now()
+    err = s.db.WithContext(ctx).Model(&item).Updates(map[string]interface{}{
+        "uploaded_hash":   "",
+        "delivered_sinks": nil,
+        "last_action":     models.ItemActionRetracted,
+        "last_action_at":  now,
+        "retracted_at":    now,
+    }).Error
+    if err != nil {
+        return false, fmt.Errorf("failed to record retraction: %w", err)
+    }
+    return true, nil
+}
+
+// loadUploadState copies the catalog ID and upload time of the stored copy
+// of an item, if it has been ingested
+func (s *Server) loadUploadState(ctx context.Context, item *models.BLADEItem) error {
+    var stored models.BLADEItem
+    err := s.db.WithContext(ctx).Select("catalog_id", "uploaded_at").
+        Where("item_id = ?", item.ItemID).
+        Limit(1).Find(&stored).Error
+    if err != nil {
+        return fmt.Errorf("failed to load upload state: %w", err)
+    }
+    item.CatalogID = stored.CatalogID
+    item.UploadedAt = stored.UploadedAt
+    return nil
+}
+
+// saveItem upserts an item keyed on item_id. A tombstoned item that
+// reappears in its source is restored.
+func saveItem(db *gorm.DB, item *models.BLADEItem) error {
+    err := db.Clauses(clause.OnConflict{
+        Columns: []clause.Column{{Name: "item_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{
+            "data_type", "data", "classification_marking", "last_modified",
+            "metadata", "data_source_id", "ingestion_job_id", "content_hash", "updated_at",
+            "deleted_at",
+        }),
+    }).Create(item).Error
+    if err != nil {
+        return fmt.Errorf("failed to save item: %w", err)
+    }
+    return nil
+}
+
+// buildFilteredQuery compiles a client filter into a parameterized query
+// against the data type's table
+func (s *Server) buildFilteredQuery(ctx context.Context, dataType, filter string, limit int) (string, []StatementParameter, error) {
+    where, params, err := CompileFilter(dataType, filter)
+    if err != nil {
+        return "", nil, status.Error(codes.InvalidArgument, err.Error())
+    }
+    table, err := s.tableNameFor(ctx, dataType)
+    if err != nil {
+        return "", nil, err
+    }
+    return s.config.GetTableQuery(table, where, "", limit, 0), params, nil
+}
+
+// sourceByID loads a data source, whether or not it is enabled. ID 0 is no
+// source.
+func (s *Server) sourceByID(ctx context.Context, id uint) (*datasource.DataSource, error) {
+    if id == 0 {
+        return nil, nil
+    }
+    var source datasource.DataSource
+    if err := s.db.WithContext(ctx).First(&source, id).Error; err != nil {
+        return nil, fmt.Errorf("failed to load data source %d: %w", id, err)
+    }
+    return &source, nil
+}
+
+// defaultSource returns the data source read by requests that name only a
+// data type: its one enabled source, or nil if it has none. Which of
+// several enabled sources is meant cannot be told, so that is an error.
+func (s *Server) defaultSource(ctx context.Context, dataType string) (*datasource.DataSource, error) {
+    var sources []datasource.DataSource
+    err := s.db.WithContext(ctx).
+        Where("data_type = ? AND enabled = ?", dataType, true).
+        Order("id").
+        Limit(2).
+        Find(&sources).Error
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to look up data source: %v", err)
+    }
+    switch len(sources) {
+    case 0:
+        return nil, nil
+    case 1:
+        return &sources[0], nil
+    default:
+        return nil, status.Errorf(codes.FailedPrecondition, "data type %s has several enabled data sources", dataType)
+    }
+}
+
+// itemSource returns the data source an item is read from: the one it was
+// stored with, or else its data type's default source
+func (s *Server) itemSource(ctx context.Context, dataType, itemID string) (*datasource.DataSource, error) {
+    var stored models.BLADEItem
+    err := s.db.WithContext(ctx).Select("data_source_id").
+        Where("item_id = ? AND data_source_id > 0", itemID).
+        Limit(1).Find(&stored).Error
+    if err != nil {
+        return nil, status.Errorf(codes.Internal, "failed to look up item: %v", err)
+    }
+    if stored.DataSourceID != 0 {
+        source, err := s.sourceByID(ctx, stored.DataSourceID)
+        if err != nil {
+            return nil, status.Errorf(codes.Internal, "%v", err)
+        }
+        return source, nil
+    }
+    return s.defaultSource(ctx, dataType)
+}
+
+// tableNameFor resolves the Databricks table a request for a data type reads
+func (s *Server) tableNameFor(ctx context.Context, dataType string) (string, error) {
+    source, err := s.defaultSource(ctx, dataType)
+    if err != nil {
+        return "", err
+    }
+    return s.tableFor(source, dataType), nil
+}
+
+// tableFor returns the table of a data source, or of a data type without one
+func (s *Server) tableFor(source *datasource.DataSource, dataType string) string {
+    if source != nil {
+        return s.sourceTableName(source)
+    }
+    return s.config.GetDatabricksTable(dataType)
+}
+
+// sourceTableName returns the table a data source reads from, falling back
+// to the configured table of its data type
+func (s *Server) sourceTableName(source *datasource.DataSource) string {
+    if source.TableName != "" {
+        return source.GetFullTableName()
+    }
+    return s.config.GetDatabricksTable(source.DataType)
+}
+
+// defaultTableName returns the configured table for a data type
+func (s *Server) defaultTableName(dataType string) string {
+    if tableName, ok := s.config.DataTypeMapping[dataType]; ok {
+        return tableName
+    }
+    return fmt.Sprintf("blade_%s_data", dataType)
+}
+
+// mergeMetadata merges extra fields into an item's metadata
+func mergeMetadata(item *models.BLADEItem, extra map[string]interface{}) error {
+    metadata := map[string]interface{}{}
+    if len(item.Metadata) > 0 {
+        if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
+            return err
+        }
+    }
+    for k, v := range extra {
+        metadata[k] = v
+    }
+
+    data, err := json.Marshal(metadata)
+    if err != nil {
+        return err
+    }
+    item.Metadata = data
+    return nil
+}
+
+// toProtoItem converts a BLADE item model to its API representation
+func toProtoItem(item *models.BLADEItem) (*pb.BLADEItem, error) {
+    var data map[string]interface{}
+    if len(item.Data) > 0 {
+        if err := json.Unmarshal(item.Data, &data); err != nil {
+            return nil, fmt.Errorf("failed to parse item data: %w", err)
+        }
+    }
+
+    dataStruct, err := structpb.NewStruct(data)
+    if err != nil {
+        return nil, fmt.Errorf("failed to convert item data: %w", err)
+    }
+
+    metadata := map[string]string{}
+    if len(item.Metadata) > 0 {
+        var raw map[string]interface{}
+        if err := json.Unmarshal(item.Metadata, &raw); err == nil {
+            for k, v := range raw {
+                metadata[k] = fmt.Sprint(v)
+            }
+        }
+    }
+    if item.CatalogID != "" {
+        metadata["catalog_id"] = item.CatalogID
+    }
+    if item.UploadedAt != nil {
+        metadata["uploaded_at"] = item.UploadedAt.UTC().Format(time.RFC3339)
+    }
+
+    return &pb.BLADEItem{
+        ItemId:                item.ItemID,
+        DataType:              item.DataType,
+        Data:                  dataStruct,
+        ClassificationMarking: item.ClassificationMarking,
+        LastModified:          timestamppb.New(item.LastModified),
+        Metadata:              metadata,
+    }, nil
+}
+
+// toProtoDataSource converts a data source model to its API representation
+func toProtoDataSource(source *datasource.DataSource) (*pb.DataSource, error) {
+    params := map[string]interface{}{}
+    if len(source.Parameters) > 0 {
+        if err := json.Unmarshal(source.Parameters, &params); err != nil {
+            return nil, err
+        }
+    }
+    params["warehouse_id"] = source.WarehouseID
+    params["catalog"] = source.CatalogName
+    params["schema"] = source.SchemaName
+    params["table"] = source.TableName
+    params["sync_enabled"] = source.SyncEnabled
+    params["sync_schedule"] = source.SyncSchedule
+    params["item_count"] = source.ItemCount
+    if source.LastSyncTime != nil {
+        params["last_sync_time"] = source.LastSyncTime.Format(time.RFC3339)
+    }
+    if source.LastSyncStatus != "" {
+        params["last_sync_status"] = source.LastSyncStatus
+    }
+    if source.NextSyncTime != nil {
+        params["next_sync_time"] = source.NextSyncTime.Format(time.RFC3339)
+    }
+    if source.LastScheduledRun != nil {
+        params["last_scheduled_run"] = source.LastScheduledRun.Format(time.RFC3339)
+    }
+    if source.WatermarkColumn != "" {
+        params["watermark_column"] = source.WatermarkColumn
+    }
+    if source.Watermark != nil {
+        params["watermark"] = source.Watermark.Format(time.RFC3339Nano)
+    }
+
+    config, err := structpb.NewStruct(params)
+    if err != nil {
+        return nil, err
+    }
+
+    return &pb.DataSource{
+        Name:        source.TypeName,
+        DisplayName: source.DisplayName,
+        DataType:    source.DataType,
+        Enabled:     source.Enabled,
+        Config:      config,
+    }, nil
+}
+
+// ingestionResult accumulates per-item ingestion outcomes; it is safe for
+// concurrent use
+type ingestionResult struct {
+    mu        sync.Mutex
+    processed int32
+    succeeded int32
+    failed    int32
+    skipped   int32
+    retracted int32
+    retries   int
+    errors    []string
+    aborted   bool
+}
+
+func newIngestionResult() *ingestionResult {
+    return &ingestionResult{}
+}
+
+// record records the outcome of ingesting one item
+func (r *ingestionResult) record(itemID string, err error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.processed++
+    if errors.Is(err, errItemUnchanged) {
+        r.skipped++
+        return
+    }
+    if err != nil {
+        r.failed++
+        r.errors = append(r.errors, fmt.Sprintf("%s: %v", itemID, err))
+        return
+    }
+    r.succeeded++
+}
+
+// recordRetracted records an item retracted because it left the source
+func (r *ingestionResult) recordRetracted() {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.processed++
+    r.retracted++
+}
+
+// abort records an error that stopped the operation before all items were seen
+func (r *ingestionResult) abort(err error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.aborted = true
+    r.errors = append(r.errors, err.Error())
+}
+
+// toProto converts the result to an IngestionResponse
+func (r *ingestionResult) toProto() *pb.IngestionResponse {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    resp := &pb.IngestionResponse{
+        ItemsProcessed: r.processed,
+        ItemsSucceeded: r.succeeded,
+        ItemsFailed:    r.failed,
+        Errors:         r.errors,
+        Details: map[string]string{
+            "retries":   strconv.Itoa(r.retries),
+            "skipped":   strconv.Itoa(int(r.skipped)),
+            "retracted": strconv.Itoa(int(r.retracted)),
+        },
+    }
+
+    switch {
+    case r.failed == 0 && !r.aborted:
+        resp.Status = "SUCCESS"
+    case r.succeeded == 0:
+        resp.Status = "FAILED"
+    default:
+        resp.Status = "PARTIAL"
+    }
+
+    return resp
+}
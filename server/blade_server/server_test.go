@@ -0,0 +1,299 @@
+package blade_server
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+
+    "blade-ingestion-service/database/datasource"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "gorm.io/driver/postgres"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+)
+
+// newMockServer returns a Server whose database is a sqlmock
+func newMockServer(t *testing.T, config *utils.Config) (*Server, sqlmock.Sqlmock) {
+    sqlDB, mock, err := sqlmock.New()
+    require.NoError(t, err)
+    t.Cleanup(func() { sqlDB.Close() })
+
+    db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+    require.NoError(t, err)
+
+    if config == nil {
+        config = &utils.Config{}
+    }
+    if config.DBSchema == "" {
+        config.DBSchema = "blade"
+    }
+    return NewServer(config, db), mock
+}
+
+// recordingDB is a database driver that accepts every statement and records
+// it. Queries are answered by rowsFor, or return no rows.
+type recordingDB struct {
+    mu         sync.Mutex
+    statements []recordedStatement
+    rowsFor    func(query string, args []driver.Value) ([]string, [][]driver.Value)
+}
+
+type recordedStatement struct {
+    SQL  string
+    Args []driver.Value
+}
+
+// newRecordingServer returns a Server whose database is a recordingDB
+func newRecordingServer(t *testing.T, config *utils.Config) (*Server, *recordingDB) {
+    rec := &recordingDB{}
+    sqlDB := sql.OpenDB(rec)
+    t.Cleanup(func() { sqlDB.Close() })
+
+    db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+    require.NoError(t, err)
+
+    if config == nil {
+        config = &utils.Config{}
+    }
+    if config.DBSchema == "" {
+        config.DBSchema = "blade"
+    }
+    return NewServer(config, db), rec
+}
+
+// position returns the index of the first statement at or after from that
+// contains fragment, or -1
+func (r *recordingDB) position(fragment string, from int) int {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for i := from; i < len(r.statements); i++ {
+        if strings.Contains(r.statements[i].SQL, fragment) {
+            return i
+        }
+    }
+    return -1
+}
+
+// matching returns the recorded statements containing every fragment
+func (r *recordingDB) matching(fragments ...string) []recordedStatement {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    var found []recordedStatement
+next:
+    for _, st := range r.statements {
+        for _, f := range fragments {
+            if !strings.Contains(st.SQL, f) {
+                continue next
+            }
+        }
+        found = append(found, st)
+    }
+    return found
+}
+
+func (r *recordingDB) record(query string, args []driver.NamedValue) []driver.Value {
+    values := make([]driver.Value, len(args))
+    for i, arg := range args {
+        values[i] = arg.Value
+    }
+    r.mu.Lock()
+    r.statements = append(r.statements, recordedStatement{SQL: query, Args: values})
+    r.mu.Unlock()
+    return values
+}
+
+func (r *recordingDB) Connect(context.Context) (driver.Conn, error) { return recordingConn{r}, nil }
+func (r *recordingDB) Driver() driver.Driver                        { return nil }
+
+type recordingConn struct{ db *recordingDB }
+
+func (c recordingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c recordingConn) Close() error                       { return nil }
+
+// Transaction boundaries are recorded as BEGIN, COMMIT and ROLLBACK
+func (c recordingConn) Begin() (driver.Tx, error) { c.db.record("BEGIN", nil); return c, nil }
+func (c recordingConn) Commit() error              { c.db.record("COMMIT", nil); return nil }
+func (c recordingConn) Rollback() error            { c.db.record("ROLLBACK", nil); return nil }
+
+func (c recordingConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+    c.db.record(query, args)
+    return driver.RowsAffected(1), nil
+}
+
+func (c recordingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+    values := c.db.record(query, args)
+    rows := &recordingRows{}
+    if c.db.rowsFor != nil {
+        rows.columns, rows.values = c.db.rowsFor(query, values)
+    }
+    return rows, nil
+}
+
+type recordingRows struct {
+    columns []string
+    values  [][]driver.Value
+}
+
+func (r *recordingRows) Columns() []string { return r.columns }
+func (r *recordingRows) Close() error      { return nil }
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+    if len(r.values) == 0 {
+        return io.EOF
+    }
+    copy(dest, r.values[0])
+    r.values = r.values[1:]
+    return nil
+}
+
+// fakeWarehouse is a Databricks statement endpoint that records statements
+// and answers each one with the same rows, or fails them when state is
+// FAILED
+type fakeWarehouse struct {
+    mu         sync.Mutex
+    statements []string
+    columns    []string
+    rows       [][]interface{}
+    types      map[string]string
+    truncated  bool
+    state      string
+
+    // onQuery, if set, runs before each statement is answered
+    onQuery func(statement string)
+}
+
+func (f *fakeWarehouse) start(t *testing.T) string {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var req struct {
+            Statement string `json:"statement"`
+        }
+        json.NewDecoder(r.Body).Decode(&req)
+
+        f.mu.Lock()
+        f.statements = append(f.statements, req.Statement)
+        f.mu.Unlock()
+        if f.onQuery != nil {
+            f.onQuery(req.Statement)
+        }
+
+        columns := make([]map[string]string, len(f.columns))
+        for i, name := range f.columns {
+            columns[i] = map[string]string{"name": name, "type_name": f.types[name]}
+        }
+        state := f.state
+        if state == "" {
+            state = StatementSucceeded
+        }
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "statement_id": "s1",
+            "status":       map[string]string{"state": state},
+            "result":       map[string]interface{}{"data": f.rows},
+            "manifest": map[string]interface{}{
+                "schema":    map[string]interface{}{"columns": columns},
+                "truncated": f.truncated,
+            },
+        })
+    }))
+    t.Cleanup(server.Close)
+    return server.URL
+}
+
+// dataSourceRows returns data_sources rows for sqlmock
+func dataSourceRows(id int, dataType, catalog, schema, table string) *sqlmock.Rows {
+    return sqlmock.NewRows([]string{"id", "type_name", "data_type", "enabled", "catalog_name", "schema_name", "table_name"}).
+        AddRow(id, dataType+"-source", dataType, true, catalog, schema, table)
+}
+
+func TestQueryBLADEReadsSourceTable(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"total_count"}, rows: [][]interface{}{{"0"}}}
+    s, mock := newMockServer(t, &utils.Config{
+        MockDatabricksURL:  warehouse.start(t),
+        MaxRecordsPerQuery: 500,
+        BLADEDataTypes:     []string{"maintenance"},
+    })
+
+    mock.ExpectQuery(`SELECT \* FROM "data_sources" WHERE \(data_type = \$1 AND enabled = \$2\)`).
+        WithArgs("maintenance", true, 2).
+        WillReturnRows(dataSourceRows(1, "maintenance", "ops", "fleet", "maintenance_v2"))
+
+    _, err := s.QueryBLADE(context.Background(), &pb.BLADEQuery{DataType: "maintenance"})
+    require.NoError(t, err)
+    assert.NoError(t, mock.ExpectationsWereMet())
+
+    require.Len(t, warehouse.statements, 2)
+    assert.Equal(t, "SELECT * FROM ops.fleet.maintenance_v2 ORDER BY item_id ASC NULLS LAST LIMIT 501", warehouse.statements[0])
+    assert.Equal(t, "SELECT COUNT(*) AS total_count FROM ops.fleet.maintenance_v2", warehouse.statements[1])
+}
+
+func TestQueryBLADERejectsAmbiguousSource(t *testing.T) {
+    s, mock := newMockServer(t, &utils.Config{BLADEDataTypes: []string{"maintenance"}})
+
+    rows := dataSourceRows(1, "maintenance", "ops", "fleet", "maintenance_v2").
+        AddRow(2, "maintenance-archive", "maintenance", true, "ops", "archive", "maintenance_v1")
+    mock.ExpectQuery(`SELECT \* FROM "data_sources"`).WillReturnRows(rows)
+
+    _, err := s.QueryBLADE(context.Background(), &pb.BLADEQuery{DataType: "maintenance"})
+    assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+    assert.ErrorContains(t, err, "several enabled data sources")
+}
+
+func TestQueryBLADEContinuesFromPageToken(t *testing.T) {
+    warehouse := &fakeWarehouse{columns: []string{"total_count"}, rows: [][]interface{}{{"0"}}}
+    s, mock := newMockServer(t, &utils.Config{
+        MockDatabricksURL:  warehouse.start(t),
+        MaxRecordsPerQuery: 500,
+        BLADEDataTypes:     []string{"maintenance"},
+    })
+    terms, err := parseOrderBy("maintenance", "")
+    require.NoError(t, err)
+    token, err := s.pageTokens.encode(pageCursor{
+        DataType: "maintenance",
+        Query:    queryFingerprint("", terms),
+        Values:   cursorValues(map[string]interface{}{"item_id": "M-9"}, terms),
+    })
+    require.NoError(t, err)
+
+    mock.ExpectQuery(`SELECT \* FROM "data_sources"`).
+        WillReturnRows(dataSourceRows(1, "maintenance", "ops", "fleet", "maintenance_v2"))
+
+    _, err = s.QueryBLADE(context.Background(), &pb.BLADEQuery{DataType: "maintenance", PageToken: token})
+    require.NoError(t, err)
+    require.NotEmpty(t, warehouse.statements)
+    assert.Contains(t, warehouse.statements[0], "item_id > :c0")
+
+    // A token is only valid for the query it was issued for
+    _, err = s.QueryBLADE(context.Background(), &pb.BLADEQuery{DataType: "maintenance", Filter: "priority = 'HIGH'", PageToken: token})
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+    _, err = s.QueryBLADE(context.Background(), &pb.BLADEQuery{DataType: "maintenance", Offset: 10, PageToken: token})
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSourceTableName(t *testing.T) {
+    s := &Server{config: &utils.Config{DBSchema: "blade"}}
+
+    source := &datasource.DataSource{DataType: "maintenance"}
+    assert.Equal(t, "blade.blade_maintenance_data", s.sourceTableName(source))
+
+    source.TableName = "maintenance_v2"
+    assert.Equal(t, "maintenance_v2", s.sourceTableName(source))
+
+    source.CatalogName, source.SchemaName = "ops", "fleet"
+    assert.Equal(t, "ops.fleet.maintenance_v2", s.sourceTableName(source))
+}
@@ -0,0 +1,236 @@
+package blade_server
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/url"
+    "path/filepath"
+    "time"
+
+    "blade-ingestion-service/database/models"
+)
+
+// Sink types accepted in a data source's "sinks" config
+const (
+    SinkCatalog = "catalog"
+    SinkNDJSON  = "ndjson"
+    SinkWebhook = "webhook"
+)
+
+// Sink is a destination for ingested items. Delivery is at least once: an
+// item that fails on any sink is retried on the sinks that have not yet
+// accepted its current content.
+type Sink interface {
+    // Name identifies the sink in errors, logs and the delivery state of
+    // items, so sinks of one type with different destinations differ
+    Name() string
+
+    // Upload delivers items and returns one error per item along with the
+    // number of retries made
+    Upload(ctx context.Context, items []*models.BLADEItem) ([]error, int)
+
+    // Retract tells the sink an uploaded item was deleted at the source
+    Retract(ctx context.Context, item *models.BLADEItem) error
+}
+
+// sinkConfig is one entry of a data source's "sinks" config, e.g.
+//
+//     "sinks": [
+//         {"type": "catalog"},
+//         {"type": "ndjson", "path": "maintenance"},
+//         {"type": "webhook", "url": "https://example.com/hook", "secret_env": "HOOK_SECRET"}
+//     ]
+type sinkConfig struct {
+    Type string `json:"type"`
+
+    // Path is the NDJSON output directory, relative to SINK_OUTPUT_DIR
+    Path string `json:"path,omitempty"`
+
+    // URL and SecretEnv configure a webhook. The signing secret is read from
+    // the named environment variable so it is never stored in the database.
+    URL       string `json:"url,omitempty"`
+    SecretEnv string `json:"secret_env,omitempty"`
+}
+
+// defaultSinks is used by data sources without a "sinks" config
+var defaultSinks = []sinkConfig{{Type: SinkCatalog}}
+
+// parseSinkConfigs reads and validates the "sinks" entry of data source
+// parameters
+func parseSinkConfigs(params map[string]interface{}) ([]sinkConfig, error) {
+    raw, ok := params["sinks"]
+    if !ok || raw == nil {
+        return defaultSinks, nil
+    }
+
+    data, err := json.Marshal(raw)
+    if err != nil {
+        return nil, fmt.Errorf("invalid sinks: %w", err)
+    }
+    var configs []sinkConfig
+    if err := json.Unmarshal(data, &configs); err != nil {
+        return nil, fmt.Errorf("sinks must be a list of sink objects: %w", err)
+    }
+    if len(configs) == 0 {
+        return nil, fmt.Errorf("sinks must name at least one sink")
+    }
+
+    seen := make(map[sinkConfig]bool)
+    for _, c := range configs {
+        if seen[c] {
+            return nil, fmt.Errorf("duplicate %s sink", c.Type)
+        }
+        seen[c] = true
+
+        switch c.Type {
+        case SinkCatalog:
+        case SinkNDJSON:
+            if c.Path != "" && !filepath.IsLocal(c.Path) {
+                return nil, fmt.Errorf("ndjson sink path %q must be relative to the sink output directory", c.Path)
+            }
+        case SinkWebhook:
+            u, err := url.Parse(c.URL)
+            if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+                return nil, fmt.Errorf("webhook sink url %q must be an absolute http(s) URL", c.URL)
+            }
+            if c.SecretEnv == "" {
+                return nil, fmt.Errorf("webhook sink requires secret_env")
+            }
+        default:
+            return nil, fmt.Errorf("unknown sink type %q", c.Type)
+        }
+    }
+    return configs, nil
+}
+
+// newSink builds the sink described by a validated config
+func (s *Server) newSink(c sinkConfig) (Sink, error) {
+    switch c.Type {
+    case SinkCatalog:
+        return &catalogSink{uploader: s.uploader}, nil
+    case SinkNDJSON:
+        return newNDJSONSink(filepath.Join(s.config.SinkOutputDir, c.Path)), nil
+    case SinkWebhook:
+        return newWebhookSink(c.URL, c.SecretEnv, s.uploadConfig)
+    default:
+        return nil, fmt.Errorf("unknown sink type %q", c.Type)
+    }
+}
+
+// sinksFor returns the sinks configured on a data source. Items without a
+// source upload to the catalog only.
+func (s *Server) sinksFor(ctx context.Context, sourceID uint) ([]Sink, error) {
+    configs := defaultSinks
+    source, err := s.sourceByID(ctx, sourceID)
+    if err != nil {
+        return nil, err
+    }
+    if source != nil {
+        params, err := source.GetParameters()
+        if err != nil {
+            return nil, fmt.Errorf("invalid config for data source %s: %w", source.TypeName, err)
+        }
+        if configs, err = parseSinkConfigs(params); err != nil {
+            return nil, fmt.Errorf("invalid config for data source %s: %w", source.TypeName, err)
+        }
+    }
+
+    sinks := make([]Sink, 0, len(configs))
+    for _, c := range configs {
+        sink, err := s.newSink(c)
+        if err != nil {
+            return nil, err
+        }
+        sinks = append(sinks, sink)
+    }
+    return sinks, nil
+}
+
+// sinkRecord is how the NDJSON and webhook sinks represent an item
+type sinkRecord struct {
+    Action                string          `json:"action"`
+    ItemID                string          `json:"itemId"`
+    DataType              string          `json:"dataType"`
+    ClassificationMarking string          `json:"classificationMarking"`
+    ContentHash           string          `json:"contentHash,omitempty"`
+    Data                  json.RawMessage `json:"data,omitempty"`
+    Metadata              json.RawMessage `json:"metadata,omitempty"`
+    Timestamp             time.Time       `json:"timestamp"`
+}
+
+// newSinkRecord describes an action taken on an item
+func newSinkRecord(action string, item *models.BLADEItem, now time.Time) sinkRecord {
+    record := sinkRecord{
+        Action:                action,
+        ItemID:                item.ItemID,
+        DataType:              item.DataType,
+        ClassificationMarking: item.ClassificationMarking,
+        Timestamp:             now.UTC(),
+    }
+    if action != models.ItemActionRetracted {
+        record.ContentHash = item.ContentHash
+        if len(item.Data) > 0 {
+            record.Data = json.RawMessage(item.Data)
+        }
+        if len(item.Metadata) > 0 {
+            record.Metadata = json.RawMessage(item.Metadata)
+        }
+    }
+    return record
+}
+
+// uploadAction returns the action recorded for an item being uploaded
+func uploadAction(item *models.BLADEItem) string {
+    if item.LastAction == models.ItemActionUpdated {
+        return models.ItemActionUpdated
+    }
+    return models.ItemActionCreated
+}
+
+// catalogSink uploads to the HTTP catalog, creating new items and updating
+// ones it already has
+type catalogSink struct {
+    uploader *CatalogUploader
+}
+
+func (c *catalogSink) Name() string { return SinkCatalog }
+
+func (c *catalogSink) Upload(ctx context.Context, items []*models.BLADEItem) ([]error, int) {
+    errs := make([]error, len(items))
+    total := 0
+
+    // Items already in the catalog are updated; the rest are created in one batch
+    var creates []*models.BLADEItem
+    var createIdx []int
+    for i, item := range items {
+        if item.CatalogID == "" {
+            creates = append(creates, item)
+            createIdx = append(createIdx, i)
+            continue
+        }
+        previous := item.CatalogID
+        retries, err := c.uploader.updateItem(ctx, item)
+        total += retries
+        errs[i] = err
+        if item.CatalogID != previous {
+            // The catalog had lost the item and it was uploaded again
+            item.LastAction = models.ItemActionCreated
+        }
+    }
+
+    createErrs, retries := c.uploader.UploadItems(ctx, creates)
+    total += retries
+    for j, i := range createIdx {
+        errs[i] = createErrs[j]
+    }
+    return errs, total
+}
+
+func (c *catalogSink) Retract(ctx context.Context, item *models.BLADEItem) error {
+    // Items the catalog never accepted have nothing to remove
+    if item.CatalogID == "" {
+        return nil
+    }
+    return c.uploader.RetractItem(ctx, item)
+}
@@ -0,0 +1,97 @@
+package blade_server
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+
+    "blade-ingestion-service/database/models"
+)
+
+// ndjsonWriteMu serializes appends so lines from concurrent uploads never
+// interleave, even across sinks pointing at the same directory
+var ndjsonWriteMu sync.Mutex
+
+// ndjsonSink appends one JSON record per line to <dir>/<data type>.ndjson
+type ndjsonSink struct {
+    dir string
+}
+
+func newNDJSONSink(dir string) *ndjsonSink {
+    return &ndjsonSink{dir: dir}
+}
+
+func (n *ndjsonSink) Name() string { return SinkNDJSON + " " + n.dir }
+
+func (n *ndjsonSink) Upload(ctx context.Context, items []*models.BLADEItem) ([]error, int) {
+    errs := make([]error, len(items))
+    now := time.Now()
+
+    // Items are grouped by data type so each file gets a single append
+    buffers := make(map[string]*bytes.Buffer)
+    indexes := make(map[string][]int)
+    for i, item := range items {
+        line, err := json.Marshal(newSinkRecord(uploadAction(item), item, now))
+        if err != nil {
+            errs[i] = fmt.Errorf("failed to encode item: %w", err)
+            continue
+        }
+        buf, ok := buffers[item.DataType]
+        if !ok {
+            buf = &bytes.Buffer{}
+            buffers[item.DataType] = buf
+        }
+        buf.Write(line)
+        buf.WriteByte('\n')
+        indexes[item.DataType] = append(indexes[item.DataType], i)
+    }
+
+    for dataType, buf := range buffers {
+        if err := n.append(ctx, dataType, buf.Bytes()); err != nil {
+            for _, i := range indexes[dataType] {
+                errs[i] = err
+            }
+        }
+    }
+    return errs, 0
+}
+
+func (n *ndjsonSink) Retract(ctx context.Context, item *models.BLADEItem) error {
+    line, err := json.Marshal(newSinkRecord(models.ItemActionRetracted, item, time.Now()))
+    if err != nil {
+        return fmt.Errorf("failed to encode item: %w", err)
+    }
+    return n.append(ctx, item.DataType, append(line, '\n'))
+}
+
+// append writes lines to a data type's file, creating it if needed
+func (n *ndjsonSink) append(ctx context.Context, dataType string, lines []byte) error {
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+
+    ndjsonWriteMu.Lock()
+    defer ndjsonWriteMu.Unlock()
+
+    if err := os.MkdirAll(n.dir, 0o755); err != nil {
+        return fmt.Errorf("failed to create sink directory: %w", err)
+    }
+    path := filepath.Join(n.dir, dataType+".ndjson")
+    f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+    if err != nil {
+        return fmt.Errorf("failed to open %s: %w", path, err)
+    }
+    if _, err := f.Write(lines); err != nil {
+        f.Close()
+        return fmt.Errorf("failed to write %s: %w", path, err)
+    }
+    if err := f.Close(); err != nil {
+        return fmt.Errorf("failed to write %s: %w", path, err)
+    }
+    return nil
+}
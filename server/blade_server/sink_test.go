@@ -0,0 +1,96 @@
+package blade_server
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "blade-ingestion-service/database/models"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestParseSinkConfigs(t *testing.T) {
+    configs, err := parseSinkConfigs(map[string]interface{}{})
+    assert.NoError(t, err)
+    assert.Equal(t, defaultSinks, configs)
+
+    configs, err = parseSinkConfigs(map[string]interface{}{
+        "sinks": []interface{}{
+            map[string]interface{}{"type": "ndjson", "path": "out"},
+            map[string]interface{}{"type": "webhook", "url": "https://hooks.example/blade", "secret_env": "HOOK_SECRET"},
+        },
+    })
+    assert.NoError(t, err)
+    assert.Len(t, configs, 2)
+
+    invalid := []interface{}{
+        []interface{}{},
+        []interface{}{map[string]interface{}{"type": "s3"}},
+        []interface{}{map[string]interface{}{"type": "ndjson", "path": "../etc"}},
+        []interface{}{map[string]interface{}{"type": "webhook", "url": "hooks.example", "secret_env": "X"}},
+        []interface{}{map[string]interface{}{"type": "webhook", "url": "https://hooks.example"}},
+        []interface{}{map[string]interface{}{"type": "catalog"}, map[string]interface{}{"type": "catalog"}},
+    }
+    for _, sinks := range invalid {
+        _, err := parseSinkConfigs(map[string]interface{}{"sinks": sinks})
+        assert.Error(t, err, "sinks %v", sinks)
+    }
+}
+
+func TestNDJSONSinkAppends(t *testing.T) {
+    dir := t.TempDir()
+    sink := newNDJSONSink(dir)
+
+    items := testItems("MAINT-1", "MAINT-2")
+    items[1].LastAction = models.ItemActionUpdated
+    errs, _ := sink.Upload(context.Background(), items)
+    assert.Equal(t, []error{nil, nil}, errs)
+    assert.NoError(t, sink.Retract(context.Background(), items[0]))
+
+    f, err := os.Open(filepath.Join(dir, "maintenance.ndjson"))
+    assert.NoError(t, err)
+    defer f.Close()
+
+    var actions []string
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        var record sinkRecord
+        assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+        actions = append(actions, record.ItemID+":"+record.Action)
+    }
+    assert.Equal(t, []string{"MAINT-1:created", "MAINT-2:updated", "MAINT-1:retracted"}, actions)
+}
+
+func TestWebhookSinkSignsDeliveries(t *testing.T) {
+    t.Setenv("TEST_WEBHOOK_SECRET", "s3cret")
+
+    var payload webhookPayload
+    hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        expected := "sha256=" + signWebhook([]byte("s3cret"), r.Header.Get(WebhookTimestampHeader), body)
+        assert.Equal(t, expected, r.Header.Get(WebhookSignatureHeader))
+        assert.NoError(t, json.Unmarshal(body, &payload))
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer hook.Close()
+
+    sink, err := newWebhookSink(hook.URL, "TEST_WEBHOOK_SECRET", &utils.CatalogUploadConfig{})
+    assert.NoError(t, err)
+
+    errs, retries := sink.Upload(context.Background(), testItems("MAINT-1", "MAINT-2"))
+    assert.Equal(t, []error{nil, nil}, errs)
+    assert.Equal(t, 0, retries)
+    assert.Len(t, payload.Records, 2)
+    assert.Equal(t, "MAINT-2", payload.Records[1].ItemID)
+
+    _, err = newWebhookSink(hook.URL, "UNSET_WEBHOOK_SECRET", nil)
+    assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package blade_server
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "os"
+    "strconv"
+    "time"
+
+    "blade-ingestion-service/database/models"
+    "blade-ingestion-service/server/utils"
+)
+
+// Headers sent with every webhook delivery. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)); receivers
+// should recompute it and reject stale timestamps to prevent replays.
+const (
+    WebhookSignatureHeader = "X-BLADE-Signature"
+    WebhookTimestampHeader = "X-BLADE-Timestamp"
+)
+
+// webhookSink POSTs signed JSON batches of sink records to a URL
+type webhookSink struct {
+    url        string
+    secret     []byte
+    httpClient *http.Client
+    retry      *retryPolicy
+}
+
+// webhookPayload is the body of a webhook delivery
+type webhookPayload struct {
+    Records []sinkRecord `json:"records"`
+}
+
+// newWebhookSink creates a webhook sink signing with the secret held in the
+// secretEnv environment variable. Retries follow the catalog upload config.
+func newWebhookSink(url, secretEnv string, config *utils.CatalogUploadConfig) (*webhookSink, error) {
+    secret := os.Getenv(secretEnv)
+    if secret == "" {
+        return nil, fmt.Errorf("webhook secret environment variable %s is not set", secretEnv)
+    }
+    if config == nil {
+        config = &utils.CatalogUploadConfig{}
+    }
+    return &webhookSink{
+        url:        url,
+        secret:     []byte(secret),
+        httpClient: &http.Client{Timeout: config.Timeout},
+        retry:      newRetryPolicy(config.MaxRetries, config.RetryDelay, config.MaxRetryDelay),
+    }, nil
+}
+
+func (w *webhookSink) Name() string { return SinkWebhook + " " + w.url }
+
+func (w *webhookSink) Upload(ctx context.Context, items []*models.BLADEItem) ([]error, int) {
+    errs := make([]error, len(items))
+    if len(items) == 0 {
+        return errs, 0
+    }
+
+    now := time.Now()
+    records := make([]sinkRecord, len(items))
+    for i, item := range items {
+        records[i] = newSinkRecord(uploadAction(item), item, now)
+    }
+
+    // The receiver acknowledges the batch as a whole
+    retries, err := w.deliver(ctx, records)
+    for i := range errs {
+        errs[i] = err
+    }
+    return errs, retries
+}
+
+func (w *webhookSink) Retract(ctx context.Context, item *models.BLADEItem) error {
+    _, err := w.deliver(ctx, []sinkRecord{newSinkRecord(models.ItemActionRetracted, item, time.Now())})
+    return err
+}
+
+// deliver sends records in one signed request and returns the retries made
+func (w *webhookSink) deliver(ctx context.Context, records []sinkRecord) (int, error) {
+    body, err := json.Marshal(webhookPayload{Records: records})
+    if err != nil {
+        return 0, fmt.Errorf("failed to encode webhook payload: %w", err)
+    }
+
+    resp, retries, err := sendWithRetry(ctx, w.httpClient, w.retry, "webhook", func() (*http.Request, error) {
+        req, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(body))
+        if err != nil {
+            return nil, err
+        }
+        // Each attempt is signed afresh so retries carry a current timestamp
+        timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+        req.Header.Set("Content-Type", "application/json")
+        req.Header.Set(WebhookTimestampHeader, timestamp)
+        req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(w.secret, timestamp, body))
+        return req, nil
+    })
+    if err != nil {
+        return retries, err
+    }
+    resp.Body.Close()
+    return retries, nil
+}
+
+// signWebhook returns the hex HMAC-SHA256 of a delivery
+func signWebhook(secret []byte, timestamp string, body []byte) string {
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte(timestamp))
+    mac.Write([]byte("."))
+    mac.Write(body)
+    return hex.EncodeToString(mac.Sum(nil))
+}
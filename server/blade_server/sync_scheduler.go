@@ -0,0 +1,312 @@
+package blade_server
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "blade-ingestion-service/database/datasource"
+    pb "blade-ingestion-service/generated/proto"
+
+    "github.com/robfig/cron/v3"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+// schedulerReloadInterval is how often the scheduler rereads data sources to
+// pick up schedule changes made outside this process
+const schedulerReloadInterval = 30 * time.Second
+
+// syncSchedule is the validated sync configuration of a data source
+type syncSchedule struct {
+    Spec     string
+    SyncType pb.SyncJobRequest_SyncType
+}
+
+// parseSyncSchedule reads the "sync_enabled", "sync_schedule" and
+// "sync_type" entries of data source parameters. Schedules are standard
+// five-field cron expressions or descriptors such as @hourly; the sync type
+// defaults to FULL.
+func parseSyncSchedule(params map[string]interface{}) (enabled bool, schedule syncSchedule, err error) {
+    if v, ok := params["sync_enabled"]; ok {
+        if enabled, ok = v.(bool); !ok {
+            return false, schedule, fmt.Errorf("sync_enabled must be a boolean")
+        }
+    }
+    if v, ok := params["sync_schedule"]; ok {
+        if schedule.Spec, ok = v.(string); !ok {
+            return false, schedule, fmt.Errorf("sync_schedule must be a string")
+        }
+    }
+    if schedule.SyncType, err = scheduledSyncType(params); err != nil {
+        return false, schedule, err
+    }
+
+    if schedule.Spec != "" {
+        if _, err := cron.ParseStandard(schedule.Spec); err != nil {
+            return false, schedule, fmt.Errorf("invalid sync_schedule %q: %w", schedule.Spec, err)
+        }
+    } else if enabled {
+        return false, schedule, fmt.Errorf("sync_schedule is required when sync_enabled is set")
+    }
+    return enabled, schedule, nil
+}
+
+// scheduledSyncType reads the "sync_type" entry of data source parameters
+func scheduledSyncType(params map[string]interface{}) (pb.SyncJobRequest_SyncType, error) {
+    v, ok := params["sync_type"]
+    if !ok || v == nil {
+        return pb.SyncJobRequest_FULL, nil
+    }
+    name, _ := v.(string)
+    value, ok := pb.SyncJobRequest_SyncType_value[name]
+    if !ok {
+        return 0, fmt.Errorf("invalid sync_type %v", v)
+    }
+    return pb.SyncJobRequest_SyncType(value), nil
+}
+
+// syncScheduler runs data source syncs on their cron schedules. Each source
+// has at most one scheduled run in flight; a run that finds another sync
+// already running waits for it.
+type syncScheduler struct {
+    s    *Server
+    ctx  context.Context
+    cron *cron.Cron
+
+    mu      sync.Mutex
+    entries map[uint]scheduledEntry
+
+    // Signals a reload, e.g. after a data source is added or removed
+    wakeup chan struct{}
+}
+
+// scheduledEntry is a data source's registered cron entry
+type scheduledEntry struct {
+    id       cron.EntryID
+    schedule syncSchedule
+}
+
+func newSyncScheduler(s *Server) *syncScheduler {
+    return &syncScheduler{
+        s:       s,
+        ctx:     context.Background(),
+        cron:    cron.New(cron.WithLogger(cron.PrintfLogger(log.Default()))),
+        entries: make(map[uint]scheduledEntry),
+        wakeup:  make(chan struct{}, 1),
+    }
+}
+
+// start runs the scheduler until ctx is done
+func (sc *syncScheduler) start(ctx context.Context) {
+    sc.ctx = ctx
+    sc.s.jobsWG.Add(1)
+    go func() {
+        defer sc.s.jobsWG.Done()
+
+        sc.reload(ctx)
+        sc.cron.Start()
+
+        ticker := time.NewTicker(schedulerReloadInterval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ctx.Done():
+                <-sc.cron.Stop().Done()
+                return
+            case <-sc.wakeup:
+            case <-ticker.C:
+            }
+            sc.reload(ctx)
+        }
+    }()
+}
+
+// wake asks the scheduler to reload data sources soon
+func (sc *syncScheduler) wake() {
+    select {
+    case sc.wakeup <- struct{}{}:
+    default:
+    }
+}
+
+// reload registers, replaces and removes cron entries to match the enabled
+// data sources
+func (sc *syncScheduler) reload(ctx context.Context) {
+    var sources []datasource.DataSource
+    err := sc.s.db.WithContext(ctx).
+        Where("enabled = ? AND sync_enabled = ? AND sync_schedule <> ''", true, true).
+        Find(&sources).Error
+    if err != nil {
+        if ctx.Err() == nil {
+            log.Printf("Failed to load sync schedules: %v", err)
+        }
+        return
+    }
+
+    sc.mu.Lock()
+    defer sc.mu.Unlock()
+
+    wanted := make(map[uint]bool, len(sources))
+    for i := range sources {
+        source := &sources[i]
+        params, err := source.GetParameters()
+        if err != nil {
+            log.Printf("Not scheduling data source %s: %v", source.TypeName, err)
+            continue
+        }
+        syncType, err := scheduledSyncType(params)
+        if err != nil {
+            log.Printf("Not scheduling data source %s: %v", source.TypeName, err)
+            continue
+        }
+        wanted[source.ID] = true
+
+        schedule := syncSchedule{Spec: source.SyncSchedule, SyncType: syncType}
+        if current, ok := sc.entries[source.ID]; ok {
+            if current.schedule == schedule {
+                continue
+            }
+            sc.cron.Remove(current.id)
+            delete(sc.entries, source.ID)
+        }
+
+        sourceID := source.ID
+        job := cron.NewChain(cron.SkipIfStillRunning(cron.PrintfLogger(log.Default()))).
+            Then(cron.FuncJob(func() { sc.run(sourceID, schedule) }))
+        id, err := sc.cron.AddJob(schedule.Spec, job)
+        if err != nil {
+            log.Printf("Not scheduling data source %s: invalid schedule %q: %v", source.TypeName, schedule.Spec, err)
+            continue
+        }
+        sc.entries[source.ID] = scheduledEntry{id: id, schedule: schedule}
+        sc.recordNextRun(sourceID, sc.cron.Entry(id).Schedule.Next(time.Now()))
+        log.Printf("Scheduled %s sync of data source %s at %q", schedule.SyncType, source.TypeName, schedule.Spec)
+    }
+
+    for sourceID, entry := range sc.entries {
+        if !wanted[sourceID] {
+            sc.cron.Remove(entry.id)
+            delete(sc.entries, sourceID)
+            sc.recordNextRun(sourceID, time.Time{})
+        }
+    }
+}
+
+// run claims a firing, starts its sync and waits for it to finish, so the
+// entry's SkipIfStillRunning wrapper prevents overlapping runs of the same
+// source
+func (sc *syncScheduler) run(sourceID uint, schedule syncSchedule) {
+    var source datasource.DataSource
+    if err := sc.s.db.First(&source, sourceID).Error; err != nil {
+        log.Printf("Scheduled sync of data source %d skipped: %v", sourceID, err)
+        return
+    }
+
+    now := time.Now()
+    next := time.Time{}
+    if parsed, err := cron.ParseStandard(schedule.Spec); err == nil {
+        next = parsed.Next(now)
+    }
+    sc.runFiring(&source, schedule, now.Truncate(time.Minute), next)
+}
+
+// runFiring runs the sync of the firing at fired. The claim is committed
+// before the sync starts and released if it cannot start. A firing that
+// finds another sync in progress is queued until it finishes, and dropped
+// if the source's next firing is due by then.
+func (sc *syncScheduler) runFiring(source *datasource.DataSource, schedule syncSchedule, fired, next time.Time) {
+    claimed, err := sc.claim(source, fired, next)
+    if err != nil {
+        log.Printf("Scheduled sync of data source %s skipped: %v", source.TypeName, err)
+        return
+    }
+    if !claimed {
+        // Another replica runs this firing
+        return
+    }
+
+    for {
+        if running := sc.s.runningSync(); running != nil {
+            log.Printf("Scheduled sync of data source %s waits for sync %s", source.TypeName, running.ID)
+            select {
+            case <-running.done:
+            case <-sc.ctx.Done():
+                sc.release(source, fired)
+                return
+            }
+            if !next.IsZero() && !time.Now().Before(next) {
+                log.Printf("Scheduled sync of data source %s dropped: its next run was due at %s",
+                    source.TypeName, next.Format(time.RFC3339))
+                return
+            }
+            continue
+        }
+
+        job, err := sc.s.startSync(&pb.SyncJobRequest{
+            SyncType:     schedule.SyncType,
+            DataType:     source.DataType,
+            DataSourceId: uint64(source.ID),
+        })
+        if status.Code(err) == codes.FailedPrecondition && sc.s.runningSync() != nil {
+            // Another sync started first
+            continue
+        }
+        if err != nil {
+            log.Printf("Scheduled sync of data source %s skipped: %v", source.TypeName, err)
+            sc.release(source, fired)
+            return
+        }
+
+        log.Printf("Started scheduled %s sync %s of data source %s", schedule.SyncType, job.ID, source.TypeName)
+        <-job.done
+        return
+    }
+}
+
+// claim records a firing on its source, so other replicas running the same
+// schedule skip it. It returns false if the firing was already claimed.
+func (sc *syncScheduler) claim(source *datasource.DataSource, fired, next time.Time) (bool, error) {
+    result := sc.s.db.Model(&datasource.DataSource{}).
+        Where("id = ? AND (last_scheduled_run IS NULL OR last_scheduled_run < ?)", source.ID, fired).
+        Updates(map[string]interface{}{
+            "last_scheduled_run": fired,
+            "next_sync_time":     nullableTime(next),
+        })
+    return result.RowsAffected > 0, result.Error
+}
+
+// release gives up a claimed firing whose sync could not start, so another
+// replica may still run it
+func (sc *syncScheduler) release(source *datasource.DataSource, fired time.Time) {
+    var previous interface{}
+    if source.LastScheduledRun != nil {
+        previous = *source.LastScheduledRun
+    }
+    err := sc.s.db.Model(&datasource.DataSource{}).
+        Where("id = ? AND last_scheduled_run = ?", source.ID, fired).
+        Update("last_scheduled_run", previous).Error
+    if err != nil {
+        log.Printf("Failed to release scheduled sync of data source %s: %v", source.TypeName, err)
+    }
+}
+
+// recordNextRun stores a source's next fire time; a zero time clears it
+func (sc *syncScheduler) recordNextRun(sourceID uint, next time.Time) {
+    err := sc.s.db.Model(&datasource.DataSource{}).
+        Where("id = ?", sourceID).
+        Update("next_sync_time", nullableTime(next)).Error
+    if err != nil {
+        log.Printf("Failed to record next sync time of data source %d: %v", sourceID, err)
+    }
+}
+
+// nullableTime maps the zero time to SQL NULL
+func nullableTime(t time.Time) interface{} {
+    if t.IsZero() {
+        return nil
+    }
+    return t
+}
@@ -0,0 +1,154 @@
+package blade_server
+
+import (
+    "database/sql/driver"
+    "strings"
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/datasource"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestParseSyncSchedule(t *testing.T) {
+    enabled, schedule, err := parseSyncSchedule(map[string]interface{}{})
+    assert.NoError(t, err)
+    assert.False(t, enabled)
+    assert.Equal(t, syncSchedule{SyncType: pb.SyncJobRequest_FULL}, schedule)
+
+    enabled, schedule, err = parseSyncSchedule(map[string]interface{}{
+        "sync_enabled":  true,
+        "sync_schedule": "*/15 * * * *",
+        "sync_type":     "INCREMENTAL",
+    })
+    assert.NoError(t, err)
+    assert.True(t, enabled)
+    assert.Equal(t, "*/15 * * * *", schedule.Spec)
+    assert.Equal(t, pb.SyncJobRequest_INCREMENTAL, schedule.SyncType)
+
+    _, schedule, err = parseSyncSchedule(map[string]interface{}{"sync_schedule": "@hourly"})
+    assert.NoError(t, err)
+    assert.Equal(t, "@hourly", schedule.Spec)
+
+    for _, params := range []map[string]interface{}{
+        {"sync_enabled": true},
+        {"sync_enabled": "yes", "sync_schedule": "@daily"},
+        {"sync_enabled": true, "sync_schedule": "every minute"},
+        {"sync_enabled": true, "sync_schedule": "* * * * * *"},
+        {"sync_enabled": true, "sync_schedule": "@daily", "sync_type": "PARTIAL"},
+    } {
+        _, _, err := parseSyncSchedule(params)
+        assert.Error(t, err, "params %v", params)
+    }
+}
+
+// newSchedulerServer serves one enabled maintenance data source from a
+// recording database
+func newSchedulerServer(t *testing.T) (*Server, *recordingDB) {
+    warehouse := &fakeWarehouse{}
+    s, rec := newRecordingServer(t, &utils.Config{
+        MockDatabricksURL:  warehouse.start(t),
+        MaxRecordsPerQuery: 100,
+        BLADEDataTypes:     []string{"maintenance"},
+    })
+    rec.rowsFor = func(query string, _ []driver.Value) ([]string, [][]driver.Value) {
+        switch {
+        case strings.Contains(query, `FROM "data_sources"`):
+            return []string{"id", "type_name", "data_type", "enabled"},
+                [][]driver.Value{{int64(1), "maintenance-source", "maintenance", true}}
+        case strings.Contains(query, `INSERT INTO "ingestion_jobs"`):
+            return []string{"id"}, [][]driver.Value{{int64(5)}}
+        }
+        return nil, nil
+    }
+    return s, rec
+}
+
+func TestScheduledRunWaitsForRunningSync(t *testing.T) {
+    s, rec := newSchedulerServer(t)
+
+    // A manually started sync is running when the schedule fires
+    busy := newSyncJob("busy", syncJobParameters{DataTypes: []string{"maintenance"}})
+    s.currentSync = busy
+
+    finished := make(chan struct{})
+    go func() {
+        defer close(finished)
+        s.scheduler.run(1, syncSchedule{Spec: "@hourly", SyncType: pb.SyncJobRequest_INCREMENTAL})
+    }()
+
+    // The firing is claimed, and the claim committed, before the run queues
+    require.Eventually(t, func() bool { return len(rec.matching(`"last_scheduled_run"=`)) > 0 }, time.Second, 5*time.Millisecond)
+    time.Sleep(20 * time.Millisecond)
+    assert.Empty(t, rec.matching(`INSERT INTO "ingestion_jobs"`))
+
+    busy.finish(JobStatusCompleted)
+    close(busy.done)
+    select {
+    case <-finished:
+    case <-time.After(5 * time.Second):
+        t.Fatal("the queued run did not finish")
+    }
+
+    // The queued run started its sync under the claim it already held
+    claims := rec.matching(`"last_scheduled_run"=`)
+    require.Len(t, claims, 1)
+    claim := rec.position(`"last_scheduled_run"=`, 0)
+    assert.Greater(t, rec.position(`INSERT INTO "ingestion_jobs"`, 0), claim)
+    assert.Equal(t, -1, rec.position("ROLLBACK", 0))
+
+    require.NotSame(t, busy, s.currentSync)
+    assert.Equal(t, pb.SyncJobRequest_INCREMENTAL, s.currentSync.SyncType)
+    assert.Equal(t, []string{"maintenance"}, s.currentSync.DataTypes)
+    assert.Equal(t, uint(1), s.currentSync.DataSourceID)
+}
+
+func TestQueuedRunDroppedOnceNextFiringIsDue(t *testing.T) {
+    s, rec := newSchedulerServer(t)
+    busy := newSyncJob("busy", syncJobParameters{DataTypes: []string{"maintenance"}})
+    s.currentSync = busy
+
+    source := &datasource.DataSource{TypeName: "maintenance-source", DataType: "maintenance"}
+    source.ID = 1
+    now := time.Now()
+    finished := make(chan struct{})
+    go func() {
+        defer close(finished)
+        s.scheduler.runFiring(source, syncSchedule{Spec: "* * * * *"}, now.Truncate(time.Minute), now.Add(20*time.Millisecond))
+    }()
+
+    // The running sync outlasts the time to the next firing
+    time.Sleep(50 * time.Millisecond)
+    busy.finish(JobStatusCompleted)
+    close(busy.done)
+    select {
+    case <-finished:
+    case <-time.After(5 * time.Second):
+        t.Fatal("the queued run did not finish")
+    }
+
+    assert.Same(t, busy, s.currentSync)
+    assert.Empty(t, rec.matching(`INSERT INTO "ingestion_jobs"`))
+}
+
+func TestScheduledRunReleasesClaimWhenSyncCannotStart(t *testing.T) {
+    s, rec := newSchedulerServer(t)
+
+    // The source's data type is not served, so its sync cannot start
+    s.config.BLADEDataTypes = []string{"sortie"}
+    previous := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+    source := &datasource.DataSource{TypeName: "maintenance-source", DataType: "maintenance", LastScheduledRun: &previous}
+    source.ID = 1
+    fired := time.Now().Truncate(time.Minute)
+    s.scheduler.runFiring(source, syncSchedule{Spec: "@hourly"}, fired, fired.Add(time.Hour))
+
+    assert.Empty(t, rec.matching(`INSERT INTO "ingestion_jobs"`))
+    releases := rec.matching(`UPDATE "data_sources" SET "last_scheduled_run"=$1`, `AND last_scheduled_run = $`)
+    require.Len(t, releases, 1)
+    assert.Equal(t, previous, releases[0].Args[0])
+    assert.Contains(t, releases[0].Args, fired)
+}
@@ -0,0 +1,72 @@
+package blade_server
+
+import (
+    "testing"
+    "time"
+
+    "blade-ingestion-service/database/datasource"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestBuildIncrementalQuery(t *testing.T) {
+    s := &Server{config: &utils.Config{
+        DBSchema:           "blade",
+        MaxRecordsPerQuery: 500,
+        SyncOverlapWindow:  5 * time.Minute,
+    }}
+    source := &datasource.DataSource{DataType: "maintenance", WatermarkColumn: "actual_completion"}
+
+    // Without a watermark every row is read, in watermark order
+    query, params, err := s.buildIncrementalQuery(source, "", 0, nil)
+    assert.NoError(t, err)
+    assert.Equal(t, "SELECT * FROM blade.blade_maintenance_data ORDER BY actual_completion ASC NULLS FIRST, item_id ASC LIMIT 500", query)
+    assert.Empty(t, params)
+
+    mark := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+    source.Watermark = &mark
+    query, params, err = s.buildIncrementalQuery(source, "priority = 'HIGH'", 100, nil)
+    assert.NoError(t, err)
+    assert.Equal(t, "SELECT * FROM blade.blade_maintenance_data WHERE (priority = :f0) AND actual_completion >= :watermark ORDER BY actual_completion ASC NULLS FIRST, item_id ASC LIMIT 100", query)
+    assert.Len(t, params, 2)
+    assert.Equal(t, "watermark", params[1].Name)
+    assert.Equal(t, "2024-03-01T11:55:00Z", *params[1].Value)
+
+    // A source's own table replaces the data type's default
+    source.CatalogName, source.SchemaName, source.TableName = "ops", "fleet", "maintenance_v2"
+    query, _, err = s.buildIncrementalQuery(source, "", 0, nil)
+    assert.NoError(t, err)
+    assert.Equal(t, "SELECT * FROM ops.fleet.maintenance_v2 WHERE actual_completion >= :watermark ORDER BY actual_completion ASC NULLS FIRST, item_id ASC LIMIT 500", query)
+}
+
+func TestWatermarkTracker(t *testing.T) {
+    w := &watermarkTracker{column: "last_modified"}
+    w.observe(map[string]interface{}{"last_modified": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)})
+    w.observe(map[string]interface{}{"last_modified": "2024-01-03T00:00:00Z"})
+    w.observe(map[string]interface{}{"last_modified": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+    w.observe(map[string]interface{}{"last_modified": nil})
+    assert.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), w.max)
+
+}
+
+func TestWatermarkColumn(t *testing.T) {
+    column, err := watermarkColumn("maintenance", map[string]interface{}{})
+    assert.NoError(t, err)
+    assert.Equal(t, defaultWatermarkColumn, column)
+
+    column, err = watermarkColumn("sortie", map[string]interface{}{"watermark_column": "actual_arrival"})
+    assert.NoError(t, err)
+    assert.Equal(t, "actual_arrival", column)
+
+    for _, bad := range []interface{}{
+        "updated_at; DROP TABLE x",
+        "updated_at",     // not a column of the data type
+        "actual_arrival", // a sortie column
+        "priority",       // not a timestamp
+        42,
+    } {
+        _, err = watermarkColumn("maintenance", map[string]interface{}{"watermark_column": bad})
+        assert.Error(t, err, "%v", bad)
+    }
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "log"
+    "net"
+    "net/http"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "syscall"
+    "time"
+
+    "blade-ingestion-service/database"
+    pb "blade-ingestion-service/generated/proto"
+    "blade-ingestion-service/server/blade_server"
+    "blade-ingestion-service/server/utils"
+
+    "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+    "github.com/joho/godotenv"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/reflection"
+)
+
+// swaggerDir holds the generated OpenAPI documents
+const swaggerDir = "swagger"
+
+// swaggerUIPage renders Swagger UI against the generated API document
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>BLADE Ingestion Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({
+        url: "/swagger/apidocs.swagger.json",
+        dom_id: "#swagger-ui",
+      });
+    };
+  </script>
+</body>
+</html>`
+
+func main() {
+    rollback := flag.Int("rollback", 0, "roll back the given number of migrations and exit")
+    migrateOnly := flag.Bool("migrate", false, "apply pending migrations and exit")
+    flag.Parse()
+
+    // A missing .env file is fine; the environment may already be populated
+    if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+        log.Printf("Warning: failed to load .env file: %v", err)
+    }
+
+    config, err := utils.LoadConfig()
+    if err != nil {
+        log.Fatalf("Failed to load configuration: %v", err)
+    }
+
+    db, err := database.Connect(config)
+    if err != nil {
+        log.Fatalf("Failed to connect to database: %v", err)
+    }
+    defer database.Close(db)
+
+    if *rollback > 0 {
+        if err := database.Rollback(db, *rollback); err != nil {
+            log.Fatalf("Rollback failed: %v", err)
+        }
+        return
+    }
+
+    if err := database.Migrate(db); err != nil {
+        log.Fatalf("Migration failed: %v", err)
+    }
+    if *migrateOnly {
+        return
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    bladeServer := blade_server.NewServer(config, db)
+    if err := bladeServer.Start(ctx); err != nil {
+        log.Fatalf("Failed to start background workers: %v", err)
+    }
+
+    // Start gRPC server
+    grpcAddr := net.JoinHostPort(config.Host, config.GRPCPort)
+    listener, err := net.Listen("tcp", grpcAddr)
+    if err != nil {
+        log.Fatalf("Failed to listen on %s: %v", grpcAddr, err)
+    }
+
+    grpcServer := grpc.NewServer()
+    pb.RegisterBLADEIngestionServiceServer(grpcServer, bladeServer)
+    reflection.Register(grpcServer)
+
+    go func() {
+        log.Printf("gRPC server listening on %s", grpcAddr)
+        if err := grpcServer.Serve(listener); err != nil {
+            log.Fatalf("gRPC server failed: %v", err)
+        }
+    }()
+
+    // Start REST gateway. Its connection to the gRPC server outlives the
+    // signal so requests in flight can drain during Shutdown.
+    gatewayCtx, closeGateway := context.WithCancel(context.Background())
+    defer closeGateway()
+    httpServer, err := newHTTPServer(gatewayCtx, config)
+    if err != nil {
+        log.Fatalf("Failed to create REST gateway: %v", err)
+    }
+
+    go func() {
+        log.Printf("REST gateway listening on %s", httpServer.Addr)
+        if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            log.Fatalf("REST gateway failed: %v", err)
+        }
+    }()
+
+    <-ctx.Done()
+    log.Printf("Shutting down...")
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ProcessingTimeout)
+    defer cancel()
+
+    if err := httpServer.Shutdown(shutdownCtx); err != nil {
+        log.Printf("REST gateway shutdown error: %v", err)
+    }
+    closeGateway()
+    stopGRPC(shutdownCtx, grpcServer)
+
+    if err := bladeServer.Shutdown(shutdownCtx); err != nil {
+        log.Printf("Job drain incomplete: %v", err)
+    }
+
+    log.Printf("Shutdown complete")
+}
+
+// stopGRPC stops the gRPC server gracefully, closing any connections still
+// open when ctx expires
+func stopGRPC(ctx context.Context, grpcServer *grpc.Server) {
+    stopped := make(chan struct{})
+    go func() {
+        grpcServer.GracefulStop()
+        close(stopped)
+    }()
+
+    select {
+    case <-stopped:
+    case <-ctx.Done():
+        log.Printf("gRPC graceful stop timed out: %v", ctx.Err())
+        grpcServer.Stop()
+        <-stopped
+    }
+}
+
+// newHTTPServer builds the REST gateway and Swagger UI server. The gateway's
+// gRPC connection is closed when ctx is cancelled.
+func newHTTPServer(ctx context.Context, config *utils.Config) (*http.Server, error) {
+    gwMux := runtime.NewServeMux()
+    opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+
+    grpcEndpoint := net.JoinHostPort(dialHost(config.Host), config.GRPCPort)
+    if err := pb.RegisterBLADEIngestionServiceHandlerFromEndpoint(ctx, gwMux, grpcEndpoint, opts); err != nil {
+        return nil, fmt.Errorf("failed to register gateway: %w", err)
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle("/", gwMux)
+
+    if config.EnableSwaggerUI {
+        mux.HandleFunc("/swagger/apidocs.swagger.json", func(w http.ResponseWriter, r *http.Request) {
+            w.Header().Set("Content-Type", "application/json")
+            http.ServeFile(w, r, filepath.Join(swaggerDir, "apidocs.swagger.json"))
+        })
+        mux.HandleFunc("/swagger-ui/", func(w http.ResponseWriter, r *http.Request) {
+            w.Header().Set("Content-Type", "text/html; charset=utf-8")
+            fmt.Fprint(w, swaggerUIPage)
+        })
+        log.Printf("Swagger UI available at http://%s:%s/swagger-ui/", config.Host, config.RESTPort)
+    }
+
+    return &http.Server{
+        Addr:              net.JoinHostPort(config.Host, config.RESTPort),
+        Handler:           mux,
+        ReadHeaderTimeout: 10 * time.Second,
+    }, nil
+}
+
+// dialHost returns the host to reach a server bound to host, which is
+// localhost when it listens on every interface
+func dialHost(host string) string {
+    if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+        return "localhost"
+    }
+    return host
+}
@@ -21,12 +21,16 @@ type Config struct {
     DBPassword string
     DBName     string
     DBSchema   string
+    DBMaxOpenConns    int
+    DBMaxIdleConns    int
+    DBConnMaxLifetime time.Duration
     
     // Mock Databricks Configuration
     MockDatabricksURL    string
     MockDatabricksToken  string
     MockWarehouseID      string
     MockRequestTimeout   time.Duration
+    MockResultDisposition string
     
     // Catalog Configuration
     CatalogURL           string
@@ -35,10 +39,14 @@ type Config struct {
     CatalogBatchSize     int
     CatalogRetryAttempts int
     
+    // Sink Configuration
+    SinkOutputDir string
+    
     // Data Processing Configuration
     DefaultClassification string
     MaxRecordsPerQuery   int
     EnableDataValidation bool
+    PageTokenSecret      string
     
     // BLADE-specific Configuration
     BLADEDataTypes []string
@@ -48,6 +56,16 @@ type Config struct {
     ConcurrentUploads  int
     RateLimitPerSecond int
     ProcessingTimeout  time.Duration
+    QueryJobWorkers    int
+    JobMaxResumes      int
+    
+    // Sync Configuration
+    SyncOverlapWindow time.Duration
+    SyncConcurrency   int
+    
+    // Outbox Configuration
+    OutboxPollInterval time.Duration
+    OutboxMaxAttempts  int
     
     // Logging
     LogLevel  string
@@ -74,12 +92,16 @@ func LoadConfig() (*Config, error) {
         DBPassword: os.Getenv("APP_DB_ADMIN_PASSWORD"),
         DBName:     getEnvOrDefault("PG_DATABASE", "blade_ingestion"),
         DBSchema:   getEnvOrDefault("DB_SCHEMA_NAME", "public"),
+        DBMaxOpenConns:    getIntOrDefault("DB_MAX_OPEN_CONNS", 20),
+        DBMaxIdleConns:    getIntOrDefault("DB_MAX_IDLE_CONNS", 5),
+        DBConnMaxLifetime: getDurationOrDefault("DB_CONN_MAX_LIFETIME", 30*time.Minute),
         
         // Mock Databricks
         MockDatabricksURL:   getEnvOrDefault("MOCK_DATABRICKS_URL", "http://localhost:8080"),
         MockDatabricksToken: os.Getenv("MOCK_DATABRICKS_TOKEN"),
         MockWarehouseID:     getEnvOrDefault("MOCK_DATABRICKS_WAREHOUSE_ID", "test-warehouse"),
         MockRequestTimeout:  getDurationOrDefault("MOCK_REQUEST_TIMEOUT", 30*time.Second),
+        MockResultDisposition: getEnvOrDefault("MOCK_RESULT_DISPOSITION", "INLINE"),
         
         // Catalog
         CatalogURL:           getEnvOrDefault("CATALOG_URL", "http://localhost:8092"),
@@ -88,10 +110,14 @@ func LoadConfig() (*Config, error) {
         CatalogBatchSize:     getIntOrDefault("CATALOG_BATCH_SIZE", 10),
         CatalogRetryAttempts: getIntOrDefault("CATALOG_RETRY_ATTEMPTS", 3),
         
+        // Sinks
+        SinkOutputDir: getEnvOrDefault("SINK_OUTPUT_DIR", "data/sinks"),
+        
         // Data processing
         DefaultClassification: getEnvOrDefault("DEFAULT_CLASSIFICATION", "UNCLASSIFIED"),
         MaxRecordsPerQuery:   getIntOrDefault("MAX_RECORDS_PER_QUERY", 1000),
         EnableDataValidation: getBoolOrDefault("ENABLE_DATA_VALIDATION", true),
+        PageTokenSecret:      os.Getenv("PAGE_TOKEN_SECRET"),
         
         // BLADE data types
         BLADEDataTypes: []string{"maintenance", "sortie", "deployment", "logistics"},
@@ -106,6 +132,16 @@ func LoadConfig() (*Config, error) {
         ConcurrentUploads:  getIntOrDefault("CONCURRENT_UPLOADS", 5),
         RateLimitPerSecond: getIntOrDefault("RATE_LIMIT_PER_SECOND", 10),
         ProcessingTimeout:  getDurationOrDefault("PROCESSING_TIMEOUT", 5*time.Minute),
+        QueryJobWorkers:    getIntOrDefault("QUERY_JOB_WORKERS", 2),
+        JobMaxResumes:      getIntOrDefault("JOB_MAX_RESUMES", 3),
+        
+        // Sync
+        SyncOverlapWindow: getDurationOrDefault("SYNC_OVERLAP_WINDOW", 5*time.Minute),
+        SyncConcurrency:   getIntOrDefault("SYNC_CONCURRENCY", 2),
+        
+        // Outbox
+        OutboxPollInterval: getDurationOrDefault("OUTBOX_POLL_INTERVAL", 10*time.Second),
+        OutboxMaxAttempts:  getIntOrDefault("OUTBOX_MAX_ATTEMPTS", 10),
         
         // Logging
         LogLevel:  getEnvOrDefault("LOG_LEVEL", "debug"),
@@ -150,28 +186,76 @@ func validateConfig(config *Config) error {
     return nil
 }
 
-// GetDatabricksQuery builds a SQL query for a BLADE data type
+// Unlimited is passed as a query limit to read every matching row instead of
+// at most MaxRecordsPerQuery
+const Unlimited = -1
+
+// GetDatabricksQuery builds a SQL query for a BLADE data type. The filter is
+// spliced in verbatim, so it must be a trusted fragment whose values are
+// referenced as :name markers and bound through statement parameters.
 func (c *Config) GetDatabricksQuery(dataType string, filter string, limit int) string {
-    tableName, exists := c.DataTypeMapping[dataType]
-    if !exists {
-        tableName = fmt.Sprintf("blade_%s_data", dataType)
-    }
-    
-    query := fmt.Sprintf("SELECT * FROM %s.%s", c.DBSchema, tableName)
+    return c.GetDatabricksOrderedQuery(dataType, filter, "", limit, 0)
+}
+
+// GetDatabricksOrderedQuery builds a SQL query with an ORDER BY clause and
+// offset. Like the filter, orderBy is spliced in verbatim and must already
+// be validated against the table's columns.
+func (c *Config) GetDatabricksOrderedQuery(dataType, filter, orderBy string, limit, offset int) string {
+    return c.GetTableQuery(c.GetDatabricksTable(dataType), filter, orderBy, limit, offset)
+}
+
+// GetTableQuery builds the same query as GetDatabricksOrderedQuery against
+// an explicit table, such as one configured on a data source
+func (c *Config) GetTableQuery(table, filter, orderBy string, limit, offset int) string {
+    query := fmt.Sprintf("SELECT * FROM %s", table)
     
     if filter != "" {
         query += " WHERE " + filter
     }
     
+    if orderBy != "" {
+        query += " ORDER BY " + orderBy
+    }
+    
     if limit > 0 {
         query += fmt.Sprintf(" LIMIT %d", limit)
-    } else if c.MaxRecordsPerQuery > 0 {
+    } else if limit != Unlimited && c.MaxRecordsPerQuery > 0 {
         query += fmt.Sprintf(" LIMIT %d", c.MaxRecordsPerQuery)
     }
     
+    if offset > 0 {
+        query += fmt.Sprintf(" OFFSET %d", offset)
+    }
+    
+    return query
+}
+
+// GetDatabricksCountQuery builds a query counting the rows matching a filter
+func (c *Config) GetDatabricksCountQuery(dataType string, filter string) string {
+    return c.GetTableCountQuery(c.GetDatabricksTable(dataType), filter)
+}
+
+// GetTableCountQuery builds a query counting the rows of a table matching a
+// filter
+func (c *Config) GetTableCountQuery(table, filter string) string {
+    query := fmt.Sprintf("SELECT COUNT(*) AS total_count FROM %s", table)
+    
+    if filter != "" {
+        query += " WHERE " + filter
+    }
+    
     return query
 }
 
+// GetDatabricksTable returns the schema-qualified table for a data type
+func (c *Config) GetDatabricksTable(dataType string) string {
+    tableName, exists := c.DataTypeMapping[dataType]
+    if !exists {
+        tableName = fmt.Sprintf("blade_%s_data", dataType)
+    }
+    return fmt.Sprintf("%s.%s", c.DBSchema, tableName)
+}
+
 // GetCatalogDataSource returns the catalog-compatible data source name
 func (c *Config) GetCatalogDataSource(dataType string) string {
     return fmt.Sprintf("BLADE Databricks: %s", dataType)
@@ -250,6 +334,8 @@ type CatalogUploadConfig struct {
 	BatchSize          int
 	MaxRetries         int
 	RetryDelay         time.Duration
+	MaxRetryDelay      time.Duration
+	Timeout            time.Duration
 	ValidateBeforeUpload bool
 	SkipDuplicates     bool
 	MetadataEnrichment bool
@@ -261,6 +347,8 @@ func (c *Config) NewCatalogUploadConfig() *CatalogUploadConfig {
 			BatchSize:            c.CatalogBatchSize,
 			MaxRetries:           c.CatalogRetryAttempts,
 			RetryDelay:           2 * time.Second,
+			MaxRetryDelay:        30 * time.Second,
+			Timeout:              c.CatalogTimeout,
 			ValidateBeforeUpload: c.EnableDataValidation,
 			SkipDuplicates:       true,
 			MetadataEnrichment:   true,
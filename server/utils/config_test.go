@@ -54,6 +54,21 @@ func TestGetDatabricksQuery(t *testing.T) {
     query = config.GetDatabricksQuery("maintenance", "priority = 'HIGH'", 10)
     expected = "SELECT * FROM public.blade_maintenance_data WHERE priority = 'HIGH' LIMIT 10"
     assert.Equal(t, expected, query)
+    
+    // Test without a limit
+    query = config.GetDatabricksQuery("maintenance", "", Unlimited)
+    expected = "SELECT * FROM public.blade_maintenance_data"
+    assert.Equal(t, expected, query)
+    
+    // Test with ordering and offset
+    query = config.GetDatabricksOrderedQuery("maintenance", "", "item_id ASC", 10, 20)
+    expected = "SELECT * FROM public.blade_maintenance_data ORDER BY item_id ASC LIMIT 10 OFFSET 20"
+    assert.Equal(t, expected, query)
+    
+    // Test count query
+    query = config.GetDatabricksCountQuery("maintenance", "priority = :f0")
+    expected = "SELECT COUNT(*) AS total_count FROM public.blade_maintenance_data WHERE priority = :f0"
+    assert.Equal(t, expected, query)
 }
 
 func TestValidateConfig(t *testing.T) {